@@ -0,0 +1,34 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func catCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cat IMAGE_REF:/PATH",
+		Short:   "print the content of a file in the image",
+		Example: `  registrycli cat 127.0.0.1:5000/repo1:v1.0:/etc/os-release`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if err := opts.ParseFileReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Cat(opts)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func copyCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "copy SRC_IMAGE_REF DST_IMAGE_REF",
+		Short:   "copy the manifest and its blobs to another repository",
+		Example: `  registrycli copy 127.0.0.1:5000/repo1:v1.0 127.0.0.1:5001/repo1:v1.0`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) < 2 {
+				return errors.ErrNeedTarget
+			}
+			if len(args) > 2 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			target, err := opts.ForReference(args[1])
+			if err != nil {
+				return err
+			}
+			if err := opts.LoadDestCredentials(target, cmd.InOrStdin()); err != nil {
+				return err
+			}
+			opts.Target = target
+
+			return action.Copy(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format when dry run, options: json text")
+	cmd.Flags().StringVar(&opts.DestUsername, "dest-username", "", "username of the destination registry")
+	cmd.Flags().StringVar(&opts.DestPassword, "dest-password", "", "password of the destination registry")
+	cmd.Flags().BoolVar(&opts.DestPasswordStdin, "dest-password-stdin", false, "read the password of the destination registry from stdin")
+	cmd.Flags().StringVar(&opts.DestPasswordFile, "dest-password-file", "", "read the password of the destination registry from the file")
+	return cmd
+}
@@ -21,6 +21,10 @@ func delCmd(opts *option.Options) *cobra.Command {
 				return errors.ErrTooManyArgs
 			}
 
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
 			if err := opts.ParseReference(args[0]); err != nil {
 				return err
 			}
@@ -31,5 +35,6 @@ func delCmd(opts *option.Options) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVar(&opts.Untag, "untag", false, "untag the tag")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format when dry run, options: json text")
 	return cmd
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func diffCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "diff IMAGE_REF_A IMAGE_REF_B",
+		Short:   "compare the manifests, configs, layers and files of two images",
+		Example: `  registrycli diff 127.0.0.1:5000/repo1:v1.4 127.0.0.1:5000/repo1:v1.5 --files`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) < 2 {
+				return errors.ErrNeedTarget
+			}
+			if len(args) > 2 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			target, err := opts.ForReference(args[1])
+			if err != nil {
+				return err
+			}
+			opts.Target = target
+
+			return action.Diff(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().BoolVar(&opts.ShowFiles, "files", false, "also compare the files of the two images")
+	return cmd
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func historyCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history IMAGE_REF",
+		Short:   "show the build history of the image with layer sizes",
+		Example: `  registrycli history 127.0.0.1:5000/repo1:v1.0`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.History(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	return cmd
+}
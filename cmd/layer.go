@@ -10,9 +10,10 @@ import (
 
 func layerCmd(opts *option.Options) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "layer LAYER_REF",
-		Short:   "Get layer's content",
-		Example: `  registrycli layer 127.0.0.1:5000/repo1@sha256:275b2e73e3dc5cbf88c41ba15962045f0d36eeaf09dfe01f259ff2a12d3326af`,
+		Use:   "layer LAYER_REF",
+		Short: "Get layer's content",
+		Example: `  registrycli layer 127.0.0.1:5000/repo1@sha256:275b2e73e3dc5cbf88c41ba15962045f0d36eeaf09dfe01f259ff2a12d3326af
+  registrycli layer 127.0.0.1:5000/repo1@sha256:275b2e73e3dc5cbf88c41ba15962045f0d36eeaf09dfe01f259ff2a12d3326af --list`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return errors.ErrNeedImageReference
@@ -21,6 +22,10 @@ func layerCmd(opts *option.Options) *cobra.Command {
 				return errors.ErrTooManyArgs
 			}
 
+			if opts.List && !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
 			if err := opts.ParseReference(args[0]); err != nil {
 				return err
 			}
@@ -31,5 +36,7 @@ func layerCmd(opts *option.Options) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&opts.Destination, "destination", "d", "./layers", "location to save layer")
+	cmd.Flags().BoolVarP(&opts.List, "list", "l", false, "list files in the layer instead of saving it")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format when listing files, options: json text")
 	return cmd
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func loginCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "login REGISTRY_ADDRESS",
+		Short:   "validate the credentials and save them for the registry",
+		Example: `  echo "${PASSWORD}" | registrycli login 127.0.0.1:5000 -u admin --password-stdin`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !checkServer(args[0]) {
+				return errors.ErrWrongRegistryAddress
+			}
+
+			opts.Server = args[0]
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Login(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.AuthFile, "auth-file", "", "save the credentials to the auth file instead of the docker config")
+	return cmd
+}
+
+func logoutCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "logout REGISTRY_ADDRESS",
+		Short:   "remove the saved credentials of the registry",
+		Example: `  registrycli logout 127.0.0.1:5000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !checkServer(args[0]) {
+				return errors.ErrWrongRegistryAddress
+			}
+
+			opts.Server = args[0]
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Logout(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.AuthFile, "auth-file", "", "remove the credentials from the auth file instead of the docker config")
+	return cmd
+}
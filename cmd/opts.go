@@ -14,6 +14,21 @@ var subCmds = []func(*option.Options) *cobra.Command{
 	inspectCmd,
 	delCmd,
 	layerCmd,
+	copyCmd,
+	pruneCmd,
+	pullCmd,
+	pushCmd,
+	verifyCmd,
+	catCmd,
+	filesCmd,
+	diffCmd,
+	historyCmd,
+	referrersCmd,
+	verifySignatureCmd,
+	sbomCmd,
+	scanCmd,
+	loginCmd,
+	logoutCmd,
 }
 
 func rootCmd() *cobra.Command {
@@ -28,12 +43,33 @@ func rootCmd() *cobra.Command {
 			DisableDefaultCmd: true,
 		},
 		Version: version.BuildVersion,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.LoadCredentials(cmd.InOrStdin()); err != nil {
+				return err
+			}
+			if opts.Platform == "" {
+				return nil
+			}
+			_, err := option.ParsePlatform(opts.Platform)
+			return err
+		},
 	}
 	root.PersistentFlags().StringVarP(&opts.Username, "username", "u", "", "registry username")
 	root.PersistentFlags().StringVarP(&opts.Password, "password", "p", "", "registry password")
+	root.PersistentFlags().BoolVar(&opts.PasswordStdin, "password-stdin", false, "read registry password from stdin")
+	root.PersistentFlags().StringVar(&opts.PasswordFile, "password-file", "", "read registry password from the file")
 	root.PersistentFlags().StringVar(&opts.Auth, "auth", "", "registry auth, base64 encoded username:password")
 	root.PersistentFlags().BoolVar(&opts.Insecure, "insecure", false, "use insecure tls")
+	root.PersistentFlags().StringVar(&opts.CAFile, "ca-file", "", "PEM encoded CA certificates to verify the registry")
+	root.PersistentFlags().StringVar(&opts.CertFile, "cert", "", "PEM encoded client certificate for mutual tls")
+	root.PersistentFlags().StringVar(&opts.KeyFile, "key", "", "PEM encoded client private key for mutual tls")
+	root.PersistentFlags().StringVar(&opts.CAFile, "tls-ca-file", "", "alias of --ca-file")
+	root.PersistentFlags().StringVar(&opts.CertFile, "tls-cert", "", "alias of --cert")
+	root.PersistentFlags().StringVar(&opts.KeyFile, "tls-key", "", "alias of --key, for verify-signature whose --key is the public key")
 	root.PersistentFlags().BoolVar(&opts.PlainHTTP, "plain-http", false, "use http without tls")
+	root.PersistentFlags().BoolVar(&opts.TokenCache, "token-cache", false, "cache registry tokens encrypted on disk and reuse them until they expire")
+	root.PersistentFlags().BoolVar(&opts.DryRun, "dry-run", false, "print the changes without applying them")
+	root.PersistentFlags().StringVar(&opts.Platform, "platform", "", "select the manifest of the platform os/arch[/variant] from manifest lists")
 
 	root.PersistentFlags().BoolVar(&opts.Debug, "debug", false, "enable debug output")
 
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func pruneCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune REPO_REF",
+		Short:   "delete manifests by retention rules",
+		Example: `  registrycli prune 127.0.0.1:5000/repo1 --keep-last 10 --keep-tag '^v[0-9.]+$' --older-than 720h`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Prune(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().IntVar(&opts.KeepLast, "keep-last", 0, "keep the N newest manifests by created time")
+	cmd.Flags().StringVar(&opts.KeepTag, "keep-tag", "", "keep the manifests whose tag matches the regular expression")
+	cmd.Flags().DurationVar(&opts.OlderThan, "older-than", 0, "only delete the manifests created before the duration")
+	return cmd
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func pullCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pull IMAGE_REF",
+		Short: "save the image as an oci image layout",
+		Example: `  registrycli pull 127.0.0.1:5000/repo1:v1.0 --oci-layout ./repo1
+  registrycli pull 127.0.0.1:5000/repo1:v1.0 --archive repo1.tar`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if (opts.OCILayout == "") == (opts.Archive == "") {
+				return errors.ErrNeedPullDestination
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Pull(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.OCILayout, "oci-layout", "", "directory to save the oci image layout")
+	cmd.Flags().StringVar(&opts.Archive, "archive", "", "tar file to save the oci image layout")
+	return cmd
+}
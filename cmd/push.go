@@ -0,0 +1,47 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func pushCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push IMAGE_REF",
+		Short: "push an oci image layout or docker archive",
+		Example: `  registrycli push 127.0.0.1:5000/repo1:v1.0 --oci-layout ./repo1
+  registrycli push 127.0.0.1:5000/repo1:v1.0 --archive repo1.tar`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if (opts.OCILayout == "") == (opts.Archive == "") {
+				return errors.ErrNeedPushSource
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Push(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.OCILayout, "oci-layout", "", "oci image layout directory to push")
+	cmd.Flags().StringVar(&opts.Archive, "archive", "", "oci image layout or docker archive tar file to push")
+	cmd.Flags().Int64Var(&opts.ChunkSize, "chunk-size", 0, "upload blobs in chunks of the size in bytes, 0 means monolithic upload")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format when dry run, options: json text")
+	return cmd
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func referrersCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "referrers IMAGE_REF",
+		Short:   "list the signatures, SBOMs and attestations referring to the image",
+		Example: `  registrycli referrers 127.0.0.1:5000/repo1:v1.0 --artifact-type application/vnd.dev.cosign.simplesigning.v1+json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Referrers(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().StringVar(&opts.ArtifactType, "artifact-type", "", "only list referrers of the artifact type")
+	return cmd
+}
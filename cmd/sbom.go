@@ -0,0 +1,40 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func sbomCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "sbom IMAGE_REF",
+		Short:   "show the SPDX and CycloneDX documents attached to the image",
+		Example: `  registrycli sbom 127.0.0.1:5000/repo1:v1.0 --raw > sbom.spdx.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.SBOM(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().BoolVar(&opts.Raw, "raw", false, "print the raw sbom documents instead of the package summary")
+	return cmd
+}
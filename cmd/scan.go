@@ -0,0 +1,44 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func scanCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "scan REPO_REF",
+		Short:   "match the packages of all tags in the repository against a local OSV advisory database",
+		Example: `  registrycli scan 127.0.0.1:5000/repo1 --db osv-all.zip`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if opts.AdvisoryDB == "" {
+				return errors.ErrNeedAdvisoryDB
+			}
+
+			if err := opts.ParseRepositoryReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.Scan(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().StringVar(&opts.AdvisoryDB, "db", "", "OSV advisory database, a directory or zip file of OSV json files, or a json file")
+	return cmd
+}
@@ -33,6 +33,10 @@ func tagsCmd(opts *option.Options) *cobra.Command {
 				return err
 			}
 
+			if len(opts.BaseImages) > 0 {
+				opts.ShowBase = true
+			}
+
 			setDefaultOpts(opts, cmd)
 
 			return action.Tags(opts)
@@ -42,6 +46,9 @@ func tagsCmd(opts *option.Options) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.ShowType, "show-type", false, "show media type when output with text format")
 	cmd.Flags().BoolVar(&opts.ShowDigest, "show-digest", false, "show digest when output with text format")
 	cmd.Flags().BoolVar(&opts.ShowSummary, "show-summary", true, "show summary when output with text format")
+	cmd.Flags().BoolVar(&opts.ShowArtifacts, "show-artifacts", false, "list signature, attestation and sbom tags as regular tags")
+	cmd.Flags().BoolVar(&opts.ShowBase, "show-base", false, "show os distribution and base image of each tag")
+	cmd.Flags().StringSliceVar(&opts.BaseImages, "base-image", nil, "known base image reference to match against, can be specified multiple times")
 	cmd.Flags().StringVar(&opts.Sort, "sort", "tag", "sort method, options: tag size created")
 	return cmd
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"registry-cli/pkg/action"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/spf13/cobra"
+)
+
+func verifySignatureCmd(opts *option.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "verify-signature IMAGE_REF",
+		Short:   "verify the cosign signatures of the image with a public key",
+		Example: `  registrycli verify-signature 127.0.0.1:5000/repo1:v1.0 --key cosign.pub`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.ErrNeedImageReference
+			}
+			if len(args) > 1 {
+				return errors.ErrTooManyArgs
+			}
+
+			if !opts.IsSupportedOutput() {
+				return errors.ErrUnknownOutput
+			}
+
+			if opts.Key == "" {
+				return errors.ErrNeedPublicKey
+			}
+
+			if err := opts.ParseReference(args[0]); err != nil {
+				return err
+			}
+
+			setDefaultOpts(opts, cmd)
+
+			return action.VerifySignature(opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", option.TextOutput, "output format, options: json text")
+	cmd.Flags().StringVar(&opts.Key, "key", "", "PEM encoded public key file, such as cosign.pub")
+	return cmd
+}
@@ -0,0 +1,194 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/schema2"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type baseImage struct {
+	reference string
+	layers    []digest.Digest
+}
+
+type imageBase struct {
+	os        string
+	osVersion string
+	base      string
+}
+
+func loadBaseImages(opts *option.Options) []baseImage {
+	var bases []baseImage
+	for _, ref := range opts.BaseImages {
+		found, err := loadBaseImage(opts, ref)
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`load base image "%s"`, ref), err)
+			continue
+		}
+		bases = append(bases, found...)
+	}
+	return bases
+}
+
+func loadBaseImage(opts *option.Options, ref string) ([]baseImage, error) {
+	baseOpts, err := opts.ForReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := client.NewClient(baseOpts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return nil, err
+	}
+	repo, err := cli.NewRepository(baseOpts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return nil, err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return nil, err
+	}
+	man, err := fetchManifest(baseOpts, manifestService)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`fetch manifest of base image "%s"`, ref), err)
+		return nil, err
+	}
+	found, err := baseImageLayers(opts, manifestService, man, map[digest.Digest]bool{})
+	if err != nil {
+		return nil, err
+	}
+	bases := make([]baseImage, 0, len(found))
+	for _, layers := range found {
+		bases = append(bases, baseImage{reference: ref, layers: layers})
+	}
+	return bases, nil
+}
+
+func baseImageLayers(opts *option.Options, manifestService distribution.ManifestService, man distribution.Manifest, seen map[digest.Digest]bool) ([][]digest.Digest, error) {
+	list, ok := man.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		layers, err := imageLayers(man)
+		if err != nil {
+			return nil, err
+		}
+		digests := make([]digest.Digest, 0, len(layers))
+		for _, layer := range layers {
+			digests = append(digests, layer.Digest)
+		}
+		return [][]digest.Digest{digests}, nil
+	}
+
+	var found [][]digest.Digest
+	for _, ref := range list.Manifests {
+		if seen[ref.Digest] || ref.Annotations[referenceTypeAnnotation] == attestationManifestType {
+			continue
+		}
+		seen[ref.Digest] = true
+		child, err := manifestService.Get(opts.Ctx, ref.Digest)
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, ref.Digest), err)
+			return nil, err
+		}
+		layers, err := baseImageLayers(opts, manifestService, child, seen)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, layers...)
+	}
+	return found, nil
+}
+
+func matchBaseImage(bases []baseImage, layers []distribution.Descriptor) string {
+	matched, longest := "", 0
+	for _, base := range bases {
+		if len(base.layers) == 0 || len(base.layers) > len(layers) || len(base.layers) <= longest {
+			continue
+		}
+		prefix := true
+		for i, dgst := range base.layers {
+			if layers[i].Digest != dgst {
+				prefix = false
+				break
+			}
+		}
+		if prefix {
+			matched, longest = base.reference, len(base.layers)
+		}
+	}
+	return matched
+}
+
+func fillBaseInfo(opts *option.Options, cli *client.Client, tags []tagInfo) error {
+	bases := loadBaseImages(opts)
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	found := map[string]*imageBase{}
+	for i := range tags {
+		if artifactTagPattern.MatchString(tags[i].Tag) {
+			continue
+		}
+		info, ok := found[tags[i].Digest]
+		if !ok {
+			info, err = getImageBase(opts, repo, manifestService, digest.Digest(tags[i].Digest), bases)
+			if err != nil {
+				opts.WriteDebug(fmt.Sprintf(`get base info of "%s"`, tags[i].Tag), err)
+			}
+			found[tags[i].Digest] = info
+		}
+		if info != nil {
+			tags[i].OS, tags[i].OSVersion, tags[i].Base = info.os, info.osVersion, info.base
+		}
+	}
+	return nil
+}
+
+func getImageBase(opts *option.Options, repo distribution.Repository, manifestService distribution.ManifestService, dgst digest.Digest, bases []baseImage) (*imageBase, error) {
+	man, err := manifestService.Get(opts.Ctx, dgst)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, dgst), err)
+		return nil, err
+	}
+	if config, ok := imageConfig(man); ok && config.MediaType != schema2.MediaTypeImageConfig && config.MediaType != ocispec.MediaTypeImageConfig {
+		return nil, nil
+	}
+	layers, err := imageLayers(man)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`get layers of "%s"`, dgst), err)
+		return nil, nil
+	}
+
+	info := &imageBase{base: matchBaseImage(bases, layers)}
+	for _, name := range []string{osReleaseFile, osReleaseFallback} {
+		buf := &bytes.Buffer{}
+		err := readImageFile(opts, repo.Blobs(opts.Ctx), layers, name, buf)
+		if err == nil {
+			release := parseOSRelease(buf.Bytes())
+			info.os, info.osVersion = release.ID, release.VersionID
+			break
+		}
+		if err != errors.ErrFileNotFound {
+			opts.WriteDebug(fmt.Sprintf(`read "%s" of "%s"`, name, dgst), err)
+			break
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestMatchBaseImage(t *testing.T) {
+	const (
+		l1 = digest.Digest("sha256:1111111111111111111111111111111111111111111111111111111111111111")
+		l2 = digest.Digest("sha256:2222222222222222222222222222222222222222222222222222222222222222")
+		l3 = digest.Digest("sha256:3333333333333333333333333333333333333333333333333333333333333333")
+	)
+	bases := []baseImage{
+		{reference: "debian:11", layers: []digest.Digest{l1}},
+		{reference: "python:3.11", layers: []digest.Digest{l1, l2}},
+		{reference: "alpine:3.17", layers: []digest.Digest{l3}},
+	}
+	layers := func(digests ...digest.Digest) []distribution.Descriptor {
+		var descs []distribution.Descriptor
+		for _, d := range digests {
+			descs = append(descs, distribution.Descriptor{Digest: d})
+		}
+		return descs
+	}
+
+	for _, c := range []struct {
+		layers []distribution.Descriptor
+		expect string
+	}{
+		{layers(l1), "debian:11"},
+		{layers(l1, l3), "debian:11"},
+		{layers(l1, l2, l3), "python:3.11"},
+		{layers(l3, l1), "alpine:3.17"},
+		{layers(l2, l1), ""},
+		{nil, ""},
+	} {
+		if got := matchBaseImage(bases, c.layers); got != c.expect {
+			t.Errorf("expect base %q of %v, but got %q", c.expect, c.layers, got)
+		}
+	}
+}
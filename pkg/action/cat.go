@@ -0,0 +1,177 @@
+package action
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+const maxLinkHops = 16
+
+func Cat(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+	man, _, err = resolveImageManifest(opts, manifestService, man, opts.Digest)
+	if err != nil {
+		return err
+	}
+	layers, err := imageLayers(man)
+	if err != nil {
+		return err
+	}
+
+	return readImageFile(opts, repo.Blobs(opts.Ctx), layers, opts.Path, opts.StdOut)
+}
+
+func readImageFile(opts *option.Options, blobs distribution.BlobStore, layers []distribution.Descriptor, name string, w io.Writer) error {
+	name = cleanLayerPath(name)
+	for hops := 0; hops < maxLinkHops; hops++ {
+		next, err := catFile(opts, blobs, layers, name, w)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		opts.WriteDebug(fmt.Sprintf(`follow link "%s" to "%s"`, name, next), nil)
+		name = next
+	}
+	return errors.ErrTooManyLinks
+}
+
+type layerFile struct {
+	found   bool
+	removed bool
+	opaque  bool
+	next    string
+}
+
+func catFile(opts *option.Options, blobs distribution.BlobStore, layers []distribution.Descriptor, name string, w io.Writer) (string, error) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		file, err := catLayerFile(opts, blobs, layers[i].Digest, name, w)
+		if err != nil {
+			return "", err
+		}
+		if file.found {
+			return file.next, nil
+		}
+		if file.removed {
+			opts.WriteDebug(fmt.Sprintf(`"%s" removed in layer "%s"`, name, layers[i].Digest), nil)
+			return "", errors.ErrFileNotFound
+		}
+		if file.opaque {
+			break
+		}
+	}
+	return "", errors.ErrFileNotFound
+}
+
+func catLayerFile(opts *option.Options, blobs distribution.BlobStore, dgst digest.Digest, name string, w io.Writer) (layerFile, error) {
+	for hops := 0; hops < maxLinkHops; hops++ {
+		file, hardlink := layerFile{}, ""
+		err := walkLayer(opts, blobs, dgst, func(header *tar.Header, reader io.Reader) error {
+			entry := newLayerEntry(header)
+			dir, base := path.Split(entry.Name)
+			dir = strings.TrimSuffix(dir, "/")
+			switch entry.Whiteout {
+			case whiteoutFile:
+				if hidden := path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)); isPathOrParent(hidden, name) {
+					file.removed = true
+				}
+				return nil
+			case whiteoutDirectory:
+				if dir == "" || strings.HasPrefix(name, dir+"/") {
+					file.opaque = true
+				}
+				return nil
+			}
+			if !isPathOrParent(entry.Name, name) {
+				return nil
+			}
+
+			if entry.Name != name {
+				if header.Typeflag != tar.TypeSymlink {
+					return nil
+				}
+				file.found = true
+				file.next = cleanLayerPath(path.Join(resolveLink(entry.Name, header.Linkname), strings.TrimPrefix(name, entry.Name)))
+				return errors.ErrStopWalk
+			}
+
+			switch header.Typeflag {
+			case tar.TypeDir:
+				return errors.ErrIsDirectory
+			case tar.TypeSymlink:
+				file.found = true
+				file.next = cleanLayerPath(resolveLink(entry.Name, header.Linkname))
+				return errors.ErrStopWalk
+			case tar.TypeLink:
+				hardlink = cleanLayerPath(header.Linkname)
+				return errors.ErrStopWalk
+			case tar.TypeReg, tar.TypeRegA:
+				file.found = true
+				n, err := io.Copy(w, reader)
+				if err != nil {
+					return err
+				}
+				opts.WriteDebug(fmt.Sprintf(`write "%s" %d bytes from layer "%s"`, name, n, dgst), nil)
+				return errors.ErrStopWalk
+			}
+			return fmt.Errorf(`"%s" is not a regular file: %w`, name, errors.ErrFileNotFound)
+		})
+		if err != nil {
+			return file, err
+		}
+		if hardlink == "" {
+			if hops > 0 && !file.found {
+				return file, fmt.Errorf(`hard link target "%s" is not in layer "%s": %w`, name, dgst, errors.ErrFileNotFound)
+			}
+			return file, nil
+		}
+		opts.WriteDebug(fmt.Sprintf(`follow hard link "%s" to "%s" in layer "%s"`, name, hardlink, dgst), nil)
+		name = hardlink
+	}
+	return layerFile{}, errors.ErrTooManyLinks
+}
+
+func cleanLayerPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func resolveLink(name, link string) string {
+	if path.IsAbs(link) {
+		return link
+	}
+	return path.Join(path.Dir(name), link)
+}
+
+func isPathOrParent(parent, name string) bool {
+	return parent == name || strings.HasPrefix(name, parent+"/")
+}
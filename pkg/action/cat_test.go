@@ -0,0 +1,98 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"testing"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+type memBlobStore struct {
+	distribution.BlobStore
+	blobs map[digest.Digest][]byte
+}
+
+type memBlob struct {
+	*bytes.Reader
+}
+
+func (memBlob) Close() error {
+	return nil
+}
+
+func (s *memBlobStore) Open(ctx context.Context, dgst digest.Digest) (distribution.ReadSeekCloser, error) {
+	data, ok := s.blobs[dgst]
+	if !ok {
+		return nil, distribution.ErrBlobUnknown
+	}
+	return memBlob{bytes.NewReader(data)}, nil
+}
+
+type tarEntry struct {
+	header *tar.Header
+	body   string
+}
+
+func (s *memBlobStore) add(t *testing.T, entries ...tarEntry) distribution.Descriptor {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		e.header.Size = int64(len(e.body))
+		if err := tw.WriteHeader(e.header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, e.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dgst := digest.FromBytes(buf.Bytes())
+	s.blobs[dgst] = buf.Bytes()
+	return distribution.Descriptor{Digest: dgst, Size: int64(buf.Len())}
+}
+
+func TestReadImageFile(t *testing.T) {
+	file := func(name, body string) tarEntry {
+		return tarEntry{header: &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644}, body: body}
+	}
+	link := func(name, target string) tarEntry {
+		return tarEntry{header: &tar.Header{Name: name, Typeflag: tar.TypeLink, Linkname: target}}
+	}
+	blobs := &memBlobStore{blobs: map[digest.Digest][]byte{}}
+	layers := []distribution.Descriptor{
+		blobs.add(t, file("etc/a", "a1"), file("etc/b", "b1"), file("usr/bin/tool", "tool1"), file("etc/gone", "x")),
+		blobs.add(t, file("etc/.wh.a", ""), file("etc/a", "a2"), file("usr/bin/tool-v2", "tool2"), link("usr/bin/tool", "usr/bin/tool-v2"), file("etc/.wh.gone", "")),
+		blobs.add(t, file("etc/c", "c3"), file("usr/bin/tool-v2", "tool3"), file("etc/.wh.b", ""), link("etc/c-link", "etc/c")),
+	}
+	opts := &option.Options{Ctx: context.Background()}
+
+	for _, c := range []struct {
+		name   string
+		expect string
+		err    error
+	}{
+		{name: "etc/a", expect: "a2"},
+		{name: "etc/b", err: errors.ErrFileNotFound},
+		{name: "etc/gone", err: errors.ErrFileNotFound},
+		{name: "usr/bin/tool", expect: "tool2"},
+		{name: "etc/c-link", expect: "c3"},
+	} {
+		buf := &bytes.Buffer{}
+		err := readImageFile(opts, blobs, layers, c.name, buf)
+		if err != c.err {
+			t.Errorf("%s: expect error %v, but got %v", c.name, c.err, err)
+			continue
+		}
+		if buf.String() != c.expect {
+			t.Errorf("%s: expect %q, but got %q", c.name, c.expect, buf.String())
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package action
+
+import (
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	registryclient "github.com/distribution/distribution/registry/client"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+func Copy(opts *option.Options) error {
+	if opts.Target == nil {
+		return errors.ErrNeedTarget
+	}
+	dstOpts := opts.Target
+
+	srcCli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init source client", err)
+		return err
+	}
+	srcRepo, err := srcCli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init source repository service", err)
+		return err
+	}
+
+	dstCli, err := client.NewClient(dstOpts)
+	if err != nil {
+		opts.WriteDebug("init destination client", err)
+		return err
+	}
+	dstScopes := []client.Scope{{
+		Repository: dstOpts.Repositiory,
+		Actions:    []client.Action{client.PullAction, client.PushAction},
+	}}
+	mount := opts.Server == dstOpts.Server && opts.Repositiory != dstOpts.Repositiory
+	if mount {
+		dstScopes = append(dstScopes, client.Scope{
+			Repository: opts.Repositiory,
+			Actions:    []client.Action{client.PullAction},
+		})
+	}
+	dstRepo, err := dstCli.NewRepositoryWithScopes(dstOpts.Repositiory, dstScopes...)
+	if err != nil {
+		opts.WriteDebug("init destination repository service", err)
+		return err
+	}
+
+	srcManifests, err := srcRepo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init source manifest service", err)
+		return err
+	}
+	dstManifests, err := dstRepo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init destination manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, srcManifests)
+	if err != nil {
+		opts.WriteDebug("fetch source manifest", err)
+		return err
+	}
+
+	c := &copier{
+		opts:         opts,
+		srcRepo:      srcRepo,
+		srcManifests: srcManifests,
+		dstRepo:      dstRepo,
+		dstManifests: dstManifests,
+		mount:        mount,
+		copied:       map[digest.Digest]bool{},
+	}
+	if opts.DryRun {
+		c.plan = &changePlan{DryRun: true}
+		if err := c.plan.CheckPermission(opts, dstCli, dstOpts.Repositiory, client.PushAction); err != nil {
+			return err
+		}
+	}
+	if err := c.copyReferences(man); err != nil {
+		return err
+	}
+
+	if c.plan != nil {
+		dstChange := change{
+			Action:     changePutManifest,
+			Repository: dstOpts.Repositiory,
+			Digest:     opts.Digest.String(),
+		}
+		if dstOpts.Tag != "" {
+			dstChange.Tags = []string{dstOpts.Tag}
+		}
+		c.plan.Add(dstChange)
+		return c.plan.Output(opts)
+	}
+
+	var putOpts []distribution.ManifestServiceOption
+	if dstOpts.Tag != "" {
+		putOpts = append(putOpts, distribution.WithTag(dstOpts.Tag))
+	}
+	dgst, err := dstManifests.Put(opts.Ctx, man, putOpts...)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`put manifest "%s"`, opts.Digest), err)
+		return err
+	}
+	if dstOpts.Digest != "" && dstOpts.Digest != dgst {
+		opts.WriteDebug(fmt.Sprintf(`expect digest "%s", but got "%s"`, dstOpts.Digest, dgst), errors.ErrDigestMismatch)
+		return errors.ErrDigestMismatch
+	}
+	return nil
+}
+
+type copier struct {
+	opts         *option.Options
+	srcRepo      distribution.Repository
+	srcManifests distribution.ManifestService
+	dstRepo      distribution.Repository
+	dstManifests distribution.ManifestService
+	mount        bool
+	plan         *changePlan
+	copied       map[digest.Digest]bool
+}
+
+func (c *copier) copyReferences(man distribution.Manifest) error {
+	switch realMan := man.(type) {
+	case *manifestlist.DeserializedManifestList:
+		for _, ref := range realMan.Manifests {
+			if err := c.copyManifest(ref.Digest); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, desc := range man.References() {
+			if err := c.copyBlob(desc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *copier) copyManifest(dgst digest.Digest) error {
+	exist, err := manifestExists(c.opts, c.dstManifests, dgst)
+	if err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`check manifest "%s"`, dgst), err)
+		return err
+	}
+	if exist {
+		c.opts.WriteDebug(fmt.Sprintf(`manifest "%s" exists, skip`, dgst), nil)
+		return nil
+	}
+
+	man, err := c.srcManifests.Get(c.opts.Ctx, dgst)
+	if err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, dgst), err)
+		return err
+	}
+	if err := c.copyReferences(man); err != nil {
+		return err
+	}
+	if c.plan != nil {
+		c.plan.Add(change{
+			Action:     changePutManifest,
+			Repository: c.dstRepo.Named().Name(),
+			Digest:     dgst.String(),
+		})
+		return nil
+	}
+	if _, err := c.dstManifests.Put(c.opts.Ctx, man); err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`put manifest "%s"`, dgst), err)
+		return err
+	}
+	return nil
+}
+
+func (c *copier) copyBlob(desc distribution.Descriptor) error {
+	if c.copied[desc.Digest] {
+		return nil
+	}
+	c.copied[desc.Digest] = true
+
+	dstBlobs := c.dstRepo.Blobs(c.opts.Ctx)
+	if _, err := dstBlobs.Stat(c.opts.Ctx, desc.Digest); err == nil {
+		c.opts.WriteDebug(fmt.Sprintf(`blob "%s" exists, skip`, desc.Digest), nil)
+		return nil
+	} else if err != distribution.ErrBlobUnknown {
+		c.opts.WriteDebug(fmt.Sprintf(`stat blob "%s"`, desc.Digest), err)
+		return err
+	}
+
+	if c.plan != nil {
+		action := changeUploadBlob
+		if c.mount {
+			action = changeMountBlob
+		}
+		c.plan.Add(change{
+			Action:     action,
+			Repository: c.dstRepo.Named().Name(),
+			Digest:     desc.Digest.String(),
+		})
+		return nil
+	}
+
+	var createOpts []distribution.BlobCreateOption
+	if c.mount {
+		canonical, err := reference.WithDigest(c.srcRepo.Named(), desc.Digest)
+		if err != nil {
+			c.opts.WriteDebug(fmt.Sprintf(`refer blob "%s"`, desc.Digest), err)
+			return err
+		}
+		createOpts = append(createOpts, registryclient.WithMountFrom(canonical))
+	}
+
+	writer, err := dstBlobs.Create(c.opts.Ctx, createOpts...)
+	if err != nil {
+		if mounted, ok := err.(distribution.ErrBlobMounted); ok {
+			c.opts.WriteDebug(fmt.Sprintf(`mount blob "%s" from "%s"`, desc.Digest, mounted.From.Name()), nil)
+			return nil
+		}
+		c.opts.WriteDebug(fmt.Sprintf(`create upload for blob "%s"`, desc.Digest), err)
+		return err
+	}
+	defer writer.Close()
+
+	srcBlobs := c.srcRepo.Blobs(c.opts.Ctx)
+	if desc.Size == 0 {
+		stat, err := srcBlobs.Stat(c.opts.Ctx, desc.Digest)
+		if err != nil {
+			c.opts.WriteDebug(fmt.Sprintf(`stat blob "%s"`, desc.Digest), err)
+			writer.Cancel(c.opts.Ctx)
+			return err
+		}
+		desc.Size = stat.Size
+	}
+
+	reader, err := srcBlobs.Open(c.opts.Ctx, desc.Digest)
+	if err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`open blob "%s"`, desc.Digest), err)
+		writer.Cancel(c.opts.Ctx)
+		return err
+	}
+	defer reader.Close()
+
+	return uploadBlob(c.opts, writer, reader, desc)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/reference"
 	registryapiv2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/opencontainers/go-digest"
 )
 
 func Del(opts *option.Options) error {
@@ -26,11 +27,29 @@ func Del(opts *option.Options) error {
 		opts.WriteDebug("init repository service", err)
 		return err
 	}
+	plan := &changePlan{DryRun: opts.DryRun}
 	if opts.Untag {
 		if opts.Tag == "" {
 			opts.WriteDebug("need a tag", nil)
 			return errors.ErrNeedTag
 		}
+		if opts.DryRun {
+			desc, err := repo.Tags(opts.Ctx).Get(opts.Ctx, opts.Tag)
+			if err != nil {
+				opts.WriteDebug(fmt.Sprintf(`fetch digest for "%s"`, opts.Tag), err)
+				return err
+			}
+			if err := plan.CheckPermission(opts, cli, opts.Repositiory, client.DeleteAction); err != nil {
+				return err
+			}
+			plan.Add(change{
+				Action:     changeUntag,
+				Repository: opts.Repositiory,
+				Digest:     desc.Digest.String(),
+				Tags:       []string{opts.Tag},
+			})
+			return plan.Output(opts)
+		}
 		if err := untag(opts.Ctx, cli, repo, opts.Tag); err != nil {
 			opts.WriteDebug(fmt.Sprintf(`untag "%s"`, opts.Tag), err)
 			return err
@@ -49,14 +68,67 @@ func Del(opts *option.Options) error {
 			}
 		}
 
-		if err := manifestService.Delete(opts.Ctx, opts.Digest); err != nil {
-			opts.WriteDebug(fmt.Sprintf(`delete digest "%s"`, opts.Digest), err)
+		if opts.DryRun {
+			exist, err := manifestExists(opts, manifestService, opts.Digest)
+			if err != nil {
+				opts.WriteDebug(fmt.Sprintf(`check digest "%s"`, opts.Digest), err)
+				return err
+			}
+			if !exist {
+				return errors.ErrManifestNotFound
+			}
+			if err := plan.CheckPermission(opts, cli, opts.Repositiory, client.DeleteAction); err != nil {
+				return err
+			}
+			tags, err := lookupTags(opts, repo, opts.Digest)
+			if err != nil {
+				opts.WriteDebug(fmt.Sprintf(`lookup tags for "%s"`, opts.Digest), err)
+				return err
+			}
+			plan.Add(change{
+				Action:     changeDeleteManifest,
+				Repository: opts.Repositiory,
+				Digest:     opts.Digest.String(),
+				Tags:       tags,
+			})
+			return plan.Output(opts)
+		}
+
+		if err := deleteManifest(opts, manifestService, opts.Digest); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+func lookupTags(opts *option.Options, repo distribution.Repository, dgst digest.Digest) ([]string, error) {
+	tagService := repo.Tags(opts.Ctx)
+	all, err := tagService.All(opts.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, tag := range all {
+		desc, err := tagService.Get(opts.Ctx, tag)
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`fetch digest for "%s"`, tag), err)
+			continue
+		}
+		if desc.Digest == dgst {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func deleteManifest(opts *option.Options, manifestService distribution.ManifestService, dgst digest.Digest) error {
+	if err := manifestService.Delete(opts.Ctx, dgst); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`delete digest "%s"`, dgst), err)
+		return err
+	}
+	return nil
+}
+
 func untag(ctx context.Context, cli *client.Client, repo distribution.Repository, tag string) error {
 	ref, err := reference.WithTag(repo.Named(), tag)
 	if err != nil {
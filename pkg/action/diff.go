@@ -0,0 +1,323 @@
+package action
+
+import (
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	diffShared   = "shared"
+	diffAdded    = "added"
+	diffRemoved  = "removed"
+	diffModified = "modified"
+)
+
+type diffImage struct {
+	Reference string        `json:"reference"`
+	Digest    digest.Digest `json:"digest"`
+	MediaType string        `json:"mediaType"`
+
+	layers []distribution.Descriptor
+	image  *ocispec.Image
+	files  fileTree
+}
+
+type configChange struct {
+	Field string `json:"field"`
+	Key   string `json:"key,omitempty"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+type layerChange struct {
+	Status string        `json:"status"`
+	Digest digest.Digest `json:"digest"`
+	Size   int64         `json:"size"`
+}
+
+type fileChange struct {
+	Status string `json:"status"`
+	Name   string `json:"name"`
+	SizeA  *int64 `json:"sizeA,omitempty"`
+	SizeB  *int64 `json:"sizeB,omitempty"`
+}
+
+type imageDiff struct {
+	A      *diffImage     `json:"a"`
+	B      *diffImage     `json:"b"`
+	Config []configChange `json:"config"`
+	Layers []layerChange  `json:"layers"`
+	Files  []fileChange   `json:"files,omitempty"`
+}
+
+func (d *imageDiff) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, d)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, "IMAGE", "REFERENCE", "DIGEST", "TYPE")
+		if err != nil {
+			return err
+		}
+		if err := w.Write("A", d.A.Reference, d.A.Digest.String(), d.A.MediaType); err != nil {
+			return err
+		}
+		if err := w.Write("B", d.B.Reference, d.B.Digest.String(), d.B.MediaType); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+			return err
+		}
+		if w, err = output.NewTextWriter(opts.StdOut, "CONFIG", "KEY", "A", "B"); err != nil {
+			return err
+		}
+		for _, c := range d.Config {
+			if err := w.Write(c.Field, c.Key, c.A, c.B); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+			return err
+		}
+		if w, err = output.NewTextWriter(opts.StdOut, "LAYER", "DIGEST", "SIZE"); err != nil {
+			return err
+		}
+		for _, l := range d.Layers {
+			if err := w.Write(l.Status, l.Digest.String(), output.SizeToShow(&l.Size)); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if d.Files == nil {
+			return nil
+		}
+		if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+			return err
+		}
+		if w, err = output.NewTextWriter(opts.StdOut, "FILE", "NAME", "SIZE A", "SIZE B"); err != nil {
+			return err
+		}
+		for _, f := range d.Files {
+			if err := w.Write(f.Status, f.Name, output.SizeToShow(f.SizeA), output.SizeToShow(f.SizeB)); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+func Diff(opts *option.Options) error {
+	a, err := loadDiffImage(opts)
+	if err != nil {
+		return err
+	}
+	b, err := loadDiffImage(opts.Target)
+	if err != nil {
+		return err
+	}
+
+	d := &imageDiff{
+		A:      a,
+		B:      b,
+		Config: diffConfig(a.image, b.image),
+		Layers: diffLayers(a.layers, b.layers),
+	}
+	if opts.ShowFiles {
+		d.Files = diffFiles(a.files, b.files)
+	}
+	return d.Output(opts)
+}
+
+func loadDiffImage(opts *option.Options) (*diffImage, error) {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return nil, err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return nil, err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return nil, err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return nil, err
+	}
+	man, dgst, err := resolveImageManifest(opts, manifestService, man, opts.Digest)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, err := man.Payload()
+	if err != nil {
+		return nil, err
+	}
+	layers, err := imageLayers(man)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := opts.Server + "/" + opts.Repositiory
+	if opts.Tag != "" {
+		ref += ":" + opts.Tag
+	} else {
+		ref += "@" + opts.Digest.String()
+	}
+	image := &diffImage{
+		Reference: ref,
+		Digest:    dgst,
+		MediaType: mediaType,
+		layers:    layers,
+	}
+	if config, ok := imageConfig(man); ok {
+		image.image, _, err = parseConfig(opts, repo, config.MediaType, config.Digest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.ShowFiles {
+		if image.files, err = buildFileTree(opts, repo.Blobs(opts.Ctx), layers, true); err != nil {
+			return nil, err
+		}
+	}
+	return image, nil
+}
+
+func diffConfig(a, b *ocispec.Image) []configChange {
+	var ca, cb ocispec.ImageConfig
+	if a != nil {
+		ca = a.Config
+	}
+	if b != nil {
+		cb = b.Config
+	}
+
+	changes := []configChange{}
+	diffValue := func(field, key, va, vb string) {
+		if va != vb {
+			changes = append(changes, configChange{Field: field, Key: key, A: va, B: vb})
+		}
+	}
+	diffMap := func(field string, ma, mb map[string]string) {
+		keys := map[string]bool{}
+		for k := range ma {
+			keys[k] = true
+		}
+		for k := range mb {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValue(field, k, ma[k], mb[k])
+		}
+	}
+
+	diffMap("env", envToMap(ca.Env), envToMap(cb.Env))
+	diffValue("entrypoint", "", strings.Join(ca.Entrypoint, " "), strings.Join(cb.Entrypoint, " "))
+	diffValue("cmd", "", strings.Join(ca.Cmd, " "), strings.Join(cb.Cmd, " "))
+	diffMap("label", ca.Labels, cb.Labels)
+	diffMap("exposed-port", setToMap(ca.ExposedPorts), setToMap(cb.ExposedPorts))
+	diffValue("user", "", ca.User, cb.User)
+	diffValue("working-dir", "", ca.WorkingDir, cb.WorkingDir)
+	return changes
+}
+
+func envToMap(env []string) map[string]string {
+	m := map[string]string{}
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func setToMap(set map[string]struct{}) map[string]string {
+	m := map[string]string{}
+	for k := range set {
+		m[k] = "exposed"
+	}
+	return m
+}
+
+func diffLayers(a, b []distribution.Descriptor) []layerChange {
+	inA, inB := map[digest.Digest]bool{}, map[digest.Digest]bool{}
+	for _, l := range a {
+		inA[l.Digest] = true
+	}
+	for _, l := range b {
+		inB[l.Digest] = true
+	}
+
+	changes := []layerChange{}
+	for _, l := range a {
+		status := diffRemoved
+		if inB[l.Digest] {
+			status = diffShared
+		}
+		changes = append(changes, layerChange{Status: status, Digest: l.Digest, Size: l.Size})
+	}
+	for _, l := range b {
+		if !inA[l.Digest] {
+			changes = append(changes, layerChange{Status: diffAdded, Digest: l.Digest, Size: l.Size})
+		}
+	}
+	return changes
+}
+
+func diffFiles(a, b fileTree) []fileChange {
+	changes := []fileChange{}
+	for _, fa := range a.sorted() {
+		fb, ok := b[fa.Name]
+		if !ok {
+			changes = append(changes, fileChange{Status: diffRemoved, Name: fa.Name, SizeA: &fa.Size})
+			continue
+		}
+		if fileModified(fa, fb) {
+			changes = append(changes, fileChange{Status: diffModified, Name: fa.Name, SizeA: &fa.Size, SizeB: &fb.Size})
+		}
+	}
+	for _, fb := range b.sorted() {
+		if _, ok := a[fb.Name]; !ok {
+			changes = append(changes, fileChange{Status: diffAdded, Name: fb.Name, SizeB: &fb.Size})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func fileModified(a, b *imageFile) bool {
+	return a.Mode != b.Mode || a.Size != b.Size || a.Owner != b.Owner || a.Linkname != b.Linkname || a.Digest != b.Digest
+}
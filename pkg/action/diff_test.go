@@ -0,0 +1,75 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestDiffConfig(t *testing.T) {
+	a := &ocispec.Image{Config: ocispec.ImageConfig{
+		Env:          []string{"PATH=/bin", "MODE=dev"},
+		Entrypoint:   []string{"/app/main"},
+		Labels:       map[string]string{"version": "1.4"},
+		ExposedPorts: map[string]struct{}{"8080/tcp": {}},
+	}}
+	b := &ocispec.Image{Config: ocispec.ImageConfig{
+		Env:          []string{"PATH=/bin", "DEBUG=1"},
+		Entrypoint:   []string{"/app/main", "serve"},
+		Labels:       map[string]string{"version": "1.5"},
+		ExposedPorts: map[string]struct{}{"8080/tcp": {}, "9090/tcp": {}},
+		User:         "nobody",
+	}}
+	expect := []configChange{
+		{Field: "env", Key: "DEBUG", A: "", B: "1"},
+		{Field: "env", Key: "MODE", A: "dev", B: ""},
+		{Field: "entrypoint", A: "/app/main", B: "/app/main serve"},
+		{Field: "label", Key: "version", A: "1.4", B: "1.5"},
+		{Field: "exposed-port", Key: "9090/tcp", A: "", B: "exposed"},
+		{Field: "user", A: "", B: "nobody"},
+	}
+	if got := diffConfig(a, b); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %v, but got %v", expect, got)
+	}
+	if got := diffConfig(a, a); len(got) != 0 {
+		t.Errorf("expect no change, but got %v", got)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	file := func(name, mode, layer string, dgst digest.Digest) *imageFile {
+		return &imageFile{layerEntry: layerEntry{Name: name, Mode: mode, Size: 3, Owner: "0/0", Digest: dgst}, Layer: layer}
+	}
+	a := fileTree{
+		"etc":          file("etc", "drwxr-xr-x", "sha256:a1", ""),
+		"etc/rebuilt":  file("etc/rebuilt", "-rw-r--r--", "sha256:a1", digest.FromString("one")),
+		"etc/changed":  file("etc/changed", "-rw-r--r--", "sha256:a1", digest.FromString("one")),
+		"etc/chmod":    file("etc/chmod", "-rw-r--r--", "sha256:a1", digest.FromString("one")),
+		"etc/removed":  file("etc/removed", "-rw-r--r--", "sha256:a1", digest.FromString("one")),
+		"etc/same-dir": file("etc/same-dir", "drwxr-xr-x", "sha256:a1", ""),
+	}
+	b := fileTree{
+		"etc":          file("etc", "drwxr-xr-x", "sha256:b1", ""),
+		"etc/rebuilt":  file("etc/rebuilt", "-rw-r--r--", "sha256:b1", digest.FromString("one")),
+		"etc/changed":  file("etc/changed", "-rw-r--r--", "sha256:b1", digest.FromString("two")),
+		"etc/chmod":    file("etc/chmod", "-rwxr-xr-x", "sha256:a1", digest.FromString("one")),
+		"etc/same-dir": file("etc/same-dir", "drwxr-xr-x", "sha256:b1", ""),
+		"etc/added":    file("etc/added", "-rw-r--r--", "sha256:b1", digest.FromString("one")),
+	}
+
+	got := map[string]string{}
+	for _, c := range diffFiles(a, b) {
+		got[c.Name] = c.Status
+	}
+	expect := map[string]string{
+		"etc/added":   diffAdded,
+		"etc/changed": diffModified,
+		"etc/chmod":   diffModified,
+		"etc/removed": diffRemoved,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %v, but got %v", expect, got)
+	}
+}
@@ -0,0 +1,165 @@
+package action
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+type imageFile struct {
+	layerEntry
+	Layer string `json:"layer"`
+}
+
+func (f imageFile) Header() []string {
+	return []string{"MODE", "OWNER", "SIZE", "LAYER", "NAME"}
+}
+
+func (f *imageFile) Column() []string {
+	name := f.Name
+	if f.Linkname != "" {
+		name = name + " -> " + f.Linkname
+	}
+	return []string{f.Mode, f.Owner, output.SizeToShow(&f.Size), f.Layer, name}
+}
+
+type fileTree map[string]*imageFile
+
+func (t fileTree) apply(layer string, entries []layerEntry) {
+	var whiteouts, opaques []string
+	for _, entry := range entries {
+		dir, base := path.Split(entry.Name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch entry.Whiteout {
+		case whiteoutFile:
+			whiteouts = append(whiteouts, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+		case whiteoutDirectory:
+			opaques = append(opaques, dir)
+		}
+	}
+	for name := range t {
+		for _, w := range whiteouts {
+			if isPathOrParent(w, name) {
+				delete(t, name)
+			}
+		}
+		for _, o := range opaques {
+			if o == "" || strings.HasPrefix(name, o+"/") {
+				delete(t, name)
+			}
+		}
+	}
+	for _, entry := range entries {
+		if entry.Whiteout != "" || entry.Name == "" {
+			continue
+		}
+		if old, ok := t[entry.Name]; ok && old.Mode[0] == 'd' && entry.Mode[0] != 'd' {
+			for name := range t {
+				if strings.HasPrefix(name, entry.Name+"/") {
+					delete(t, name)
+				}
+			}
+		}
+		t[entry.Name] = &imageFile{layerEntry: entry, Layer: layer}
+	}
+}
+
+func buildFileTree(opts *option.Options, blobs distribution.BlobStore, layers []distribution.Descriptor, withDigest bool) (fileTree, error) {
+	tree := fileTree{}
+	for _, layer := range layers {
+		var entries []layerEntry
+		if err := walkLayer(opts, blobs, layer.Digest, func(header *tar.Header, reader io.Reader) error {
+			entry := newLayerEntry(header)
+			if withDigest && (header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA) && entry.Whiteout == "" {
+				digester := digest.Canonical.Digester()
+				if _, err := io.Copy(digester.Hash(), reader); err != nil {
+					opts.WriteDebug(fmt.Sprintf(`read "%s" of blob "%s"`, entry.Name, layer.Digest), err)
+					return err
+				}
+				entry.Digest = digester.Digest()
+			}
+			entries = append(entries, entry)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		tree.apply(layer.Digest.String(), entries)
+	}
+	return tree, nil
+}
+
+func (t fileTree) sorted() []*imageFile {
+	files := make([]*imageFile, 0, len(t))
+	for _, f := range t {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
+
+func Files(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+	man, _, err = resolveImageManifest(opts, manifestService, man, opts.Digest)
+	if err != nil {
+		return err
+	}
+	layers, err := imageLayers(man)
+	if err != nil {
+		return err
+	}
+
+	tree, err := buildFileTree(opts, repo.Blobs(opts.Ctx), layers, false)
+	if err != nil {
+		return err
+	}
+
+	files := tree.sorted()
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, files)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, imageFile{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err := w.Write(f.Column()...); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
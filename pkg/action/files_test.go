@@ -0,0 +1,46 @@
+package action
+
+import (
+	"archive/tar"
+	"reflect"
+	"testing"
+)
+
+func TestFileTreeApply(t *testing.T) {
+	entry := func(name string, typeflag byte) layerEntry {
+		return newLayerEntry(&tar.Header{Name: name, Typeflag: typeflag})
+	}
+	tree := fileTree{}
+	tree.apply("l1", []layerEntry{
+		entry("etc/", tar.TypeDir),
+		entry("etc/os-release", tar.TypeReg),
+		entry("tmp/", tar.TypeDir),
+		entry("tmp/a", tar.TypeReg),
+		entry("tmp/b", tar.TypeReg),
+		entry("var/cache/", tar.TypeDir),
+		entry("var/cache/x", tar.TypeReg),
+	})
+	tree.apply("l2", []layerEntry{
+		entry("tmp/.wh.a", tar.TypeReg),
+		entry("var/.wh.cache", tar.TypeReg),
+		entry("etc/os-release", tar.TypeReg),
+	})
+	tree.apply("l3", []layerEntry{
+		entry("tmp/.wh..wh..opq", tar.TypeReg),
+		entry("tmp/c", tar.TypeReg),
+	})
+
+	got := map[string]string{}
+	for _, f := range tree.sorted() {
+		got[f.Name] = f.Layer
+	}
+	expect := map[string]string{
+		"etc":            "l1",
+		"etc/os-release": "l2",
+		"tmp":            "l1",
+		"tmp/c":          "l3",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %v, but got %v", expect, got)
+	}
+}
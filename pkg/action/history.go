@@ -0,0 +1,122 @@
+package action
+
+import (
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type historyEntry struct {
+	Created    *time.Time `json:"created"`
+	CreatedBy  string     `json:"createdBy"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"emptyLayer"`
+	Layer      string     `json:"layer,omitempty"`
+	Size       int64      `json:"size"`
+}
+
+func (h historyEntry) Header() []string {
+	return []string{"CREATED", "CREATED BY", "SIZE", "COMMENT", "LAYER"}
+}
+
+func (h *historyEntry) Column() []string {
+	layer := h.Layer
+	if layer == "" {
+		layer = "-"
+	}
+	createdBy := strings.Join(strings.Fields(h.CreatedBy), " ")
+	return []string{output.TimeToShow(h.Created), createdBy, output.SizeToShow(&h.Size), h.Comment, layer}
+}
+
+func pairHistory(image *ocispec.Image, layers []distribution.Descriptor) []historyEntry {
+	entries := []historyEntry{}
+	i := 0
+	if image != nil {
+		for _, h := range image.History {
+			entry := historyEntry{
+				Created:    h.Created,
+				CreatedBy:  h.CreatedBy,
+				Comment:    h.Comment,
+				EmptyLayer: h.EmptyLayer,
+			}
+			if !h.EmptyLayer && i < len(layers) {
+				entry.Layer = layers[i].Digest.String()
+				entry.Size = layers[i].Size
+				i++
+			}
+			entries = append(entries, entry)
+		}
+	}
+	for ; i < len(layers); i++ {
+		entries = append(entries, historyEntry{
+			Layer: layers[i].Digest.String(),
+			Size:  layers[i].Size,
+		})
+	}
+	for l, r := 0, len(entries)-1; l < r; l, r = l+1, r-1 {
+		entries[l], entries[r] = entries[r], entries[l]
+	}
+	return entries
+}
+
+func History(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+	man, _, err = resolveImageManifest(opts, manifestService, man, opts.Digest)
+	if err != nil {
+		return err
+	}
+	layers, err := imageLayers(man)
+	if err != nil {
+		return err
+	}
+	var image *ocispec.Image
+	if config, ok := imageConfig(man); ok {
+		if image, _, err = parseConfig(opts, repo, config.MediaType, config.Digest); err != nil {
+			return err
+		}
+	}
+
+	entries := pairHistory(image, layers)
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, entries)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, historyEntry{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := w.Write(entry.Column()...); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
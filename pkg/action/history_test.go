@@ -0,0 +1,75 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/distribution"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestPairHistory(t *testing.T) {
+	layers := []distribution.Descriptor{
+		{Digest: "sha256:l1", Size: 10},
+		{Digest: "sha256:l2", Size: 20},
+	}
+	base := ocispec.History{CreatedBy: "ADD rootfs.tar /"}
+	env := ocispec.History{CreatedBy: "ENV PATH=/bin", EmptyLayer: true}
+	run := ocispec.History{CreatedBy: "RUN make", Comment: "buildkit"}
+
+	for _, c := range []struct {
+		name   string
+		image  *ocispec.Image
+		layers []distribution.Descriptor
+		expect []historyEntry
+	}{
+		{
+			name:   "empty layers are skipped",
+			image:  &ocispec.Image{History: []ocispec.History{base, env, run}},
+			layers: layers,
+			expect: []historyEntry{
+				{CreatedBy: "RUN make", Comment: "buildkit", Layer: "sha256:l2", Size: 20},
+				{CreatedBy: "ENV PATH=/bin", EmptyLayer: true},
+				{CreatedBy: "ADD rootfs.tar /", Layer: "sha256:l1", Size: 10},
+			},
+		},
+		{
+			name:   "more layers than history",
+			image:  &ocispec.Image{History: []ocispec.History{base}},
+			layers: layers,
+			expect: []historyEntry{
+				{Layer: "sha256:l2", Size: 20},
+				{CreatedBy: "ADD rootfs.tar /", Layer: "sha256:l1", Size: 10},
+			},
+		},
+		{
+			name:   "more history than layers",
+			image:  &ocispec.Image{History: []ocispec.History{base, run, run}},
+			layers: layers,
+			expect: []historyEntry{
+				{CreatedBy: "RUN make", Comment: "buildkit"},
+				{CreatedBy: "RUN make", Comment: "buildkit", Layer: "sha256:l2", Size: 20},
+				{CreatedBy: "ADD rootfs.tar /", Layer: "sha256:l1", Size: 10},
+			},
+		},
+		{
+			name:   "no config",
+			layers: layers,
+			expect: []historyEntry{
+				{Layer: "sha256:l2", Size: 20},
+				{Layer: "sha256:l1", Size: 10},
+			},
+		},
+		{
+			name:   "no layers",
+			image:  &ocispec.Image{},
+			expect: []historyEntry{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := pairHistory(c.image, c.layers); !reflect.DeepEqual(got, c.expect) {
+				t.Errorf("expect %+v, but got %+v", c.expect, got)
+			}
+		})
+	}
+}
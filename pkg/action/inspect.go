@@ -41,6 +41,7 @@ func IsSupportedConfigMediaTypes(mediaType string) bool {
 type manifestList struct {
 	Digest                   digest.Digest                          `json:"digest"`
 	DeserializedManifestList *manifestlist.DeserializedManifestList `json:"deserializedManifest"`
+	Annotations              map[string]string                      `json:"annotations,omitempty"`
 	Items                    []interface{}                          `json:"items,omitempty"`
 }
 
@@ -137,11 +138,28 @@ func Inspect(opts *option.Options) error {
 		return err
 	}
 
+	if list, ok := man.(*manifestlist.DeserializedManifestList); ok && opts.Platform != "" {
+		man, opts.Digest, err = resolveImageManifest(opts, manifestService, list, opts.Digest)
+		if err != nil {
+			return err
+		}
+	}
+
+	o, err := getManifestForOutput(opts, repo, manifestService, man, opts.Digest)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`get manifest "%s" for output`, opts.Digest), err)
+		return err
+	}
+	return o.Output(opts)
+}
+
+func getManifestForOutput(opts *option.Options, repo distribution.Repository, manifestService distribution.ManifestService, man distribution.Manifest, dgst digest.Digest) (manifestOutput, error) {
 	switch realMan := man.(type) {
 	case *manifestlist.DeserializedManifestList:
-		m := manifestList{
-			Digest:                   opts.Digest,
+		m := &manifestList{
+			Digest:                   dgst,
 			DeserializedManifestList: realMan,
+			Annotations:              listAnnotations(realMan),
 		}
 		for _, ref := range realMan.Manifests {
 			man, err := manifestService.Get(opts.Ctx, ref.Digest)
@@ -156,19 +174,7 @@ func Inspect(opts *option.Options) error {
 			}
 			m.Items = append(m.Items, o)
 		}
-		return m.Output(opts)
-	default:
-		o, err := getManifestForOutput(opts, repo, manifestService, man, opts.Digest)
-		if err != nil {
-			opts.WriteDebug(fmt.Sprintf(`get manifest "%s" for output`, opts.Digest), err)
-			return err
-		}
-		return o.Output(opts)
-	}
-}
-
-func getManifestForOutput(opts *option.Options, repo distribution.Repository, manifestService distribution.ManifestService, man distribution.Manifest, dgst digest.Digest) (manifestOutput, error) {
-	switch realMan := man.(type) {
+		return m, nil
 	case *schema1.SignedManifest:
 		return &manifestV1{
 			Digest:         dgst,
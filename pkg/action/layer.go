@@ -1,14 +1,125 @@
 package action
 
 import (
+	"archive/tar"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
 	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+
+	whiteoutFile      = "file"
+	whiteoutDirectory = "opaque"
 )
 
+type layerEntry struct {
+	Name     string        `json:"name"`
+	Mode     string        `json:"mode"`
+	Size     int64         `json:"size"`
+	UID      int           `json:"uid"`
+	GID      int           `json:"gid"`
+	Owner    string        `json:"owner"`
+	ModTime  time.Time     `json:"modTime"`
+	Linkname string        `json:"linkname,omitempty"`
+	Whiteout string        `json:"whiteout,omitempty"`
+	Digest   digest.Digest `json:"digest,omitempty"`
+}
+
+func (e layerEntry) Header() []string {
+	return []string{"MODE", "OWNER", "SIZE", "MODIFIED", "WHITEOUT", "NAME"}
+}
+
+func (e *layerEntry) Column() []string {
+	name := e.Name
+	if e.Linkname != "" {
+		name = fmt.Sprintf("%s -> %s", name, e.Linkname)
+	}
+	whiteout := e.Whiteout
+	if whiteout == "" {
+		whiteout = "-"
+	}
+	return []string{e.Mode, e.Owner, output.SizeToShow(&e.Size), output.TimeToShow(&e.ModTime), whiteout, name}
+}
+
+func newLayerEntry(header *tar.Header) layerEntry {
+	name := cleanLayerPath(header.Name)
+	owner := header.Uname
+	if owner == "" {
+		owner = strconv.Itoa(header.Uid)
+	}
+	group := header.Gname
+	if group == "" {
+		group = strconv.Itoa(header.Gid)
+	}
+	entry := layerEntry{
+		Name:     name,
+		Mode:     header.FileInfo().Mode().String(),
+		Size:     header.Size,
+		UID:      header.Uid,
+		GID:      header.Gid,
+		Owner:    owner + "/" + group,
+		ModTime:  header.ModTime,
+		Linkname: header.Linkname,
+	}
+	switch base := path.Base(name); {
+	case base == whiteoutOpaque:
+		entry.Whiteout = whiteoutDirectory
+	case strings.HasPrefix(base, whiteoutPrefix):
+		entry.Whiteout = whiteoutFile
+	}
+	return entry
+}
+
+func walkLayer(opts *option.Options, blobs distribution.BlobStore, dgst digest.Digest, fn func(header *tar.Header, reader io.Reader) error) error {
+	reader, err := blobs.Open(opts.Ctx, dgst)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`open blob "%s"`, dgst), err)
+		return err
+	}
+	defer reader.Close()
+
+	decompressed, compressed, err := compression.AutoDecompress(reader)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`decompress blob "%s"`, dgst), err)
+		return err
+	}
+	defer decompressed.Close()
+	opts.WriteDebug(fmt.Sprintf(`blob "%s" compressed: %t`, dgst, compressed), nil)
+
+	tr := tar.NewReader(decompressed)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`read tar entry of blob "%s"`, dgst), err)
+			return err
+		}
+		if err := fn(header, tr); err == errors.ErrStopWalk {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
 func Layer(opts *option.Options) error {
 	cli, err := client.NewClient(opts)
 	if err != nil {
@@ -21,6 +132,10 @@ func Layer(opts *option.Options) error {
 		return err
 	}
 
+	if opts.List {
+		return listLayer(opts, repo.Blobs(opts.Ctx))
+	}
+
 	reader, err := repo.Blobs(opts.Ctx).Open(opts.Ctx, opts.Digest)
 	if err != nil {
 		opts.WriteDebug("open blob", err)
@@ -63,3 +178,32 @@ func Layer(opts *option.Options) error {
 	opts.WriteDebug(fmt.Sprintf(`write "%s" %d bytes`, fn, n), nil)
 	return nil
 }
+
+func listLayer(opts *option.Options, blobs distribution.BlobStore) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		w, err := output.NewJSONArrayWriter(opts.StdOut)
+		if err != nil {
+			return err
+		}
+		if err := walkLayer(opts, blobs, opts.Digest, func(header *tar.Header, _ io.Reader) error {
+			return w.Write(newLayerEntry(header))
+		}); err != nil {
+			return err
+		}
+		return w.Finish()
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, layerEntry{}.Header()...)
+		if err != nil {
+			return err
+		}
+		if err := walkLayer(opts, blobs, opts.Digest, func(header *tar.Header, _ io.Reader) error {
+			entry := newLayerEntry(header)
+			return w.Write(entry.Column()...)
+		}); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
@@ -0,0 +1,312 @@
+package action
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	layoutBlobsDir  = "blobs"
+	layoutIndexFile = "index.json"
+)
+
+type layoutWriter interface {
+	HasBlob(dgst digest.Digest) bool
+	WriteBlob(dgst digest.Digest, size int64, reader io.Reader) error
+	WriteIndex(index *ocispec.Index) error
+	ReadIndex() (*ocispec.Index, error)
+	Close() error
+}
+
+func blobPath(dgst digest.Digest) string {
+	return filepath.Join(layoutBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+func copyVerified(writer io.Writer, reader io.Reader, dgst digest.Digest, size int64) error {
+	verified := newVerifiedReader(reader, distribution.Descriptor{Digest: dgst, Size: size})
+	if _, err := io.Copy(writer, verified); err != nil {
+		return err
+	}
+	return verified.Verify()
+}
+
+func marshalLayout() ([]byte, error) {
+	return json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+}
+
+type dirLayoutWriter struct {
+	root string
+}
+
+func newDirLayoutWriter(root string) (*dirLayoutWriter, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, os.FileMode(0755)); err != nil {
+		return nil, err
+	}
+	return &dirLayoutWriter{root: root}, nil
+}
+
+func (w *dirLayoutWriter) HasBlob(dgst digest.Digest) bool {
+	_, err := os.Stat(filepath.Join(w.root, blobPath(dgst)))
+	return err == nil
+}
+
+func (w *dirLayoutWriter) WriteBlob(dgst digest.Digest, size int64, reader io.Reader) error {
+	fn := filepath.Join(w.root, blobPath(dgst))
+	if err := os.MkdirAll(filepath.Dir(fn), os.FileMode(0755)); err != nil {
+		return err
+	}
+	tmp := fn + ".tmp"
+	writer, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0644))
+	if err != nil {
+		return err
+	}
+	if err := copyVerified(writer, reader, dgst, size); err != nil {
+		writer.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, fn)
+}
+
+func (w *dirLayoutWriter) ReadIndex() (*ocispec.Index, error) {
+	data, err := os.ReadFile(filepath.Join(w.root, layoutIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	index := &ocispec.Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func (w *dirLayoutWriter) WriteIndex(index *ocispec.Index) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(w.root, layoutIndexFile), data, os.FileMode(0644)); err != nil {
+		return err
+	}
+	layout, err := marshalLayout()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.root, ocispec.ImageLayoutFile), layout, os.FileMode(0644))
+}
+
+func (w *dirLayoutWriter) Close() error {
+	return nil
+}
+
+type tarLayoutWriter struct {
+	file    *os.File
+	writer  *tar.Writer
+	written map[digest.Digest]bool
+}
+
+func newTarLayoutWriter(fn string) (*tarLayoutWriter, error) {
+	file, err := os.OpenFile(fn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(0644))
+	if err != nil {
+		return nil, err
+	}
+	return &tarLayoutWriter{
+		file:    file,
+		writer:  tar.NewWriter(file),
+		written: map[digest.Digest]bool{},
+	}, nil
+}
+
+func (w *tarLayoutWriter) writeHeader(name string, size int64) error {
+	return w.writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filepath.ToSlash(name),
+		Size:     size,
+		Mode:     0644,
+		ModTime:  time.Unix(0, 0),
+	})
+}
+
+func (w *tarLayoutWriter) HasBlob(dgst digest.Digest) bool {
+	return w.written[dgst]
+}
+
+func (w *tarLayoutWriter) WriteBlob(dgst digest.Digest, size int64, reader io.Reader) error {
+	if err := w.writeHeader(blobPath(dgst), size); err != nil {
+		return err
+	}
+	if err := copyVerified(w.writer, reader, dgst, size); err != nil {
+		return err
+	}
+	w.written[dgst] = true
+	return nil
+}
+
+func (w *tarLayoutWriter) ReadIndex() (*ocispec.Index, error) {
+	return nil, nil
+}
+
+func (w *tarLayoutWriter) WriteIndex(index *ocispec.Index) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := w.writeHeader(layoutIndexFile, int64(len(data))); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+	layout, err := marshalLayout()
+	if err != nil {
+		return err
+	}
+	if err := w.writeHeader(ocispec.ImageLayoutFile, int64(len(layout))); err != nil {
+		return err
+	}
+	_, err = w.writer.Write(layout)
+	return err
+}
+
+func (w *tarLayoutWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+type layoutReader interface {
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+func readLayoutFile(r layoutReader, name string) ([]byte, error) {
+	reader, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+type dirLayoutReader struct {
+	root string
+}
+
+func newDirLayoutReader(root string) (*dirLayoutReader, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	return &dirLayoutReader{root: root}, nil
+}
+
+func (r *dirLayoutReader) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(r.root, filepath.FromSlash(name)))
+}
+
+func (r *dirLayoutReader) Close() error {
+	return nil
+}
+
+type tarLayoutReader struct {
+	file    *os.File
+	entries map[string]*io.SectionReader
+}
+
+func newTarLayoutReader(fn string) (*tarLayoutReader, error) {
+	file, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	r := &tarLayoutReader{
+		file:    file,
+		entries: map[string]*io.SectionReader{},
+	}
+	links := map[string]string{}
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		name := path.Clean(header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+		case tar.TypeSymlink:
+			if path.IsAbs(header.Linkname) {
+				links[name] = path.Clean(strings.TrimPrefix(header.Linkname, "/"))
+			} else {
+				links[name] = path.Join(path.Dir(name), header.Linkname)
+			}
+			continue
+		case tar.TypeLink:
+			links[name] = path.Clean(header.Linkname)
+			continue
+		default:
+			continue
+		}
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		r.entries[name] = io.NewSectionReader(file, offset, header.Size)
+	}
+	for name, target := range links {
+		for hops := 0; hops < maxLinkHops; hops++ {
+			if entry, ok := r.entries[target]; ok {
+				r.entries[name] = entry
+				break
+			}
+			next, ok := links[target]
+			if !ok {
+				break
+			}
+			target = next
+		}
+	}
+	return r, nil
+}
+
+func (r *tarLayoutReader) Open(name string) (io.ReadCloser, error) {
+	entry, ok := r.entries[path.Clean(filepath.ToSlash(name))]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(io.NewSectionReader(entry, 0, entry.Size())), nil
+}
+
+func (r *tarLayoutReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,54 @@
+package action
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarLayoutReaderLinks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := tar.NewWriter(f)
+	for _, entry := range []tarEntry{
+		{header: &tar.Header{Name: "./abc/layer.tar", Typeflag: tar.TypeReg}, body: "layer"},
+		{header: &tar.Header{Name: "def/layer.tar", Typeflag: tar.TypeSymlink, Linkname: "../abc/layer.tar"}},
+		{header: &tar.Header{Name: "ghi/layer.tar", Typeflag: tar.TypeLink, Linkname: "abc/layer.tar"}},
+		{header: &tar.Header{Name: "jkl/layer.tar", Typeflag: tar.TypeSymlink, Linkname: "/def/layer.tar"}},
+		{header: &tar.Header{Name: "dangling/layer.tar", Typeflag: tar.TypeSymlink, Linkname: "../missing.tar"}},
+	} {
+		entry.header.Size = int64(len(entry.body))
+		if err := w.WriteHeader(entry.header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	r, err := newTarLayoutReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, name := range []string{"abc/layer.tar", "def/layer.tar", "ghi/layer.tar", "jkl/layer.tar"} {
+		data, err := readLayoutFile(r, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(data) != "layer" {
+			t.Errorf("%s: expect %q, but got %q", name, "layer", data)
+		}
+	}
+	if _, err := r.Open("dangling/layer.tar"); !os.IsNotExist(err) {
+		t.Errorf("expect not exist error for a dangling link, but got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"strings"
+)
+
+func Login(opts *option.Options) error {
+	if opts.Username == "" {
+		return errors.ErrNeedUsername
+	}
+	if opts.Password == "" {
+		return errors.ErrNeedPassword
+	}
+
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	if err := cli.Ping(); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`login "%s"`, opts.Server), err)
+		return err
+	}
+
+	location, err := client.StoreCredentials(opts)
+	if err != nil {
+		opts.WriteDebug("store credentials", err)
+		return err
+	}
+	opts.WriteDebug(fmt.Sprintf(`stored credentials of "%s" in "%s"`, opts.Server, location), nil)
+	_, err = fmt.Fprintln(opts.StdOut, "Login Succeeded")
+	return err
+}
+
+func Logout(opts *option.Options) error {
+	if err := client.ClearTokenCache(opts.Server); err != nil {
+		opts.WriteDebug("clear token cache", err)
+		return err
+	}
+	locations, err := client.RemoveCredentials(opts)
+	if err != nil {
+		opts.WriteDebug("remove credentials", err)
+		return err
+	}
+	_, err = fmt.Fprintf(opts.StdOut, "Removed login credentials of %s from %s\n", opts.Server, strings.Join(locations, ", "))
+	return err
+}
@@ -0,0 +1,134 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"sort"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/ocischema"
+	"github.com/distribution/distribution/manifest/schema1"
+	"github.com/distribution/distribution/manifest/schema2"
+	registryclient "github.com/distribution/distribution/registry/client"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/registry/api/errcode"
+	registryapiv2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func fetchManifest(opts *option.Options, manifestService distribution.ManifestService) (distribution.Manifest, error) {
+	if opts.Tag != "" {
+		return manifestService.Get(opts.Ctx, "", distribution.WithTag(opts.Tag), registryclient.ReturnContentDigest(&opts.Digest))
+	}
+	return manifestService.Get(opts.Ctx, opts.Digest)
+}
+
+func resolveImageManifest(opts *option.Options, manifestService distribution.ManifestService, man distribution.Manifest, dgst digest.Digest) (distribution.Manifest, digest.Digest, error) {
+	list, ok := man.(*manifestlist.DeserializedManifestList)
+	if !ok {
+		return man, dgst, nil
+	}
+
+	platform, explicit := opts.SelectedPlatform()
+	for _, ref := range platformCandidates(platform, explicit, list.Manifests) {
+		child, err := manifestService.Get(opts.Ctx, ref.Digest)
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, ref.Digest), err)
+			return nil, "", err
+		}
+		resolved, resolvedDigest, err := resolveImageManifest(opts, manifestService, child, ref.Digest)
+		if err == errors.ErrPlatformNotFound {
+			continue
+		}
+		return resolved, resolvedDigest, err
+	}
+	opts.WriteDebug(fmt.Sprintf(`select platform %s in "%s"`, platform, dgst), errors.ErrPlatformNotFound)
+	return nil, "", errors.ErrPlatformNotFound
+}
+
+func platformCandidates(platform option.Platform, explicit bool, manifests []manifestlist.ManifestDescriptor) []manifestlist.ManifestDescriptor {
+	var matched, nested []manifestlist.ManifestDescriptor
+	for _, ref := range manifests {
+		if platform.Match(ref.Platform.OS, ref.Platform.Architecture, ref.Platform.Variant) {
+			matched = append(matched, ref)
+		} else if ref.Platform.OS == "" && isManifestList(ref.MediaType) {
+			nested = append(nested, ref)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i].Platform, matched[j].Platform
+		return platform.Rank(a.OS, a.Architecture, a.Variant) < platform.Rank(b.OS, b.Architecture, b.Variant)
+	})
+	candidates := append(matched, nested...)
+	if len(candidates) == 0 && !explicit && len(manifests) == 1 {
+		candidates = manifests
+	}
+	return candidates
+}
+
+func manifestExists(opts *option.Options, manifestService distribution.ManifestService, dgst digest.Digest) (bool, error) {
+	_, err := manifestService.Get(opts.Ctx, dgst)
+	if isManifestUnknown(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func isManifestUnknown(err error) bool {
+	errs, ok := err.(errcode.Errors)
+	if !ok {
+		return false
+	}
+	for _, e := range errs {
+		if e, ok := e.(errcode.Error); ok && e.Code == registryapiv2.ErrorCodeManifestUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+func isManifestList(mediaType string) bool {
+	return mediaType == manifestlist.MediaTypeManifestList || mediaType == ocispec.MediaTypeImageIndex
+}
+
+func listAnnotations(list *manifestlist.DeserializedManifestList) map[string]string {
+	_, payload, err := list.Payload()
+	if err != nil {
+		return nil
+	}
+	index := ocispec.Index{}
+	if err := json.Unmarshal(payload, &index); err != nil {
+		return nil
+	}
+	return index.Annotations
+}
+
+func imageLayers(man distribution.Manifest) ([]distribution.Descriptor, error) {
+	switch realMan := man.(type) {
+	case *schema2.DeserializedManifest:
+		return realMan.Layers, nil
+	case *ocischema.DeserializedManifest:
+		return realMan.Layers, nil
+	case *schema1.SignedManifest:
+		refs := realMan.References()
+		layers := make([]distribution.Descriptor, 0, len(refs))
+		for i := len(refs) - 1; i >= 0; i-- {
+			layers = append(layers, refs[i])
+		}
+		return layers, nil
+	}
+	return nil, errors.ErrUnknownManifest
+}
+
+func imageConfig(man distribution.Manifest) (distribution.Descriptor, bool) {
+	switch realMan := man.(type) {
+	case *schema2.DeserializedManifest:
+		return realMan.Config, true
+	case *ocischema.DeserializedManifest:
+		return realMan.Config, true
+	}
+	return distribution.Descriptor{}, false
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"registry-cli/pkg/option"
+	"testing"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestPlatformCandidates(t *testing.T) {
+	ref := func(dgst, os, arch, variant, mediaType string) manifestlist.ManifestDescriptor {
+		return manifestlist.ManifestDescriptor{
+			Descriptor: distribution.Descriptor{MediaType: mediaType, Digest: digest.Digest(dgst)},
+			Platform:   manifestlist.PlatformSpec{OS: os, Architecture: arch, Variant: variant},
+		}
+	}
+	amd64 := option.Platform{OS: "linux", Architecture: "amd64"}
+
+	for _, c := range []struct {
+		name      string
+		platform  option.Platform
+		explicit  bool
+		manifests []manifestlist.ManifestDescriptor
+		expect    []string
+	}{
+		{
+			name:     "first match wins",
+			platform: amd64,
+			manifests: []manifestlist.ManifestDescriptor{
+				ref("nested", "", "", "", ocispec.MediaTypeImageIndex),
+				ref("first", "linux", "amd64", "", ocispec.MediaTypeImageManifest),
+				ref("arm64", "linux", "arm64", "", ocispec.MediaTypeImageManifest),
+				ref("second", "linux", "amd64", "", ocispec.MediaTypeImageManifest),
+			},
+			expect: []string{"first", "second", "nested"},
+		},
+		{
+			name:     "closest arm variant first",
+			platform: option.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+			explicit: true,
+			manifests: []manifestlist.ManifestDescriptor{
+				ref("v5", "linux", "arm", "v5", ocispec.MediaTypeImageManifest),
+				ref("arm64", "linux", "arm64", "", ocispec.MediaTypeImageManifest),
+				ref("v6", "linux", "arm", "v6", ocispec.MediaTypeImageManifest),
+			},
+			expect: []string{"v6", "v5"},
+		},
+		{
+			name:      "single entry without platform",
+			platform:  amd64,
+			manifests: []manifestlist.ManifestDescriptor{ref("only", "", "", "", ocispec.MediaTypeImageManifest)},
+			expect:    []string{"only"},
+		},
+		{
+			name:      "explicit platform",
+			platform:  amd64,
+			explicit:  true,
+			manifests: []manifestlist.ManifestDescriptor{ref("only", "", "", "", ocispec.MediaTypeImageManifest)},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			candidates := platformCandidates(c.platform, c.explicit, c.manifests)
+			if len(candidates) != len(c.expect) {
+				t.Fatalf("expect %d candidates, but got %d", len(c.expect), len(candidates))
+			}
+			for i, e := range c.expect {
+				if candidates[i].Digest.String() != e {
+					t.Errorf("candidate %d: expect %s, but got %s", i, e, candidates[i].Digest)
+				}
+			}
+		})
+	}
+}
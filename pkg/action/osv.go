@@ -0,0 +1,251 @@
+package action
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	osvRangeEcosystem = "ECOSYSTEM"
+	osvRangeSemver    = "SEMVER"
+
+	osvEventIntroduced   = "introduced"
+	osvEventFixed        = "fixed"
+	osvEventLastAffected = "last_affected"
+)
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string              `json:"type"`
+		Events []map[string]string `json:"events"`
+	} `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+type osvEntry struct {
+	ID       string        `json:"id"`
+	Aliases  []string      `json:"aliases"`
+	Summary  string        `json:"summary"`
+	Affected []osvAffected `json:"affected"`
+}
+
+func (e *osvEntry) cve() string {
+	if strings.HasPrefix(e.ID, "CVE-") {
+		return e.ID
+	}
+	for _, alias := range e.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			return alias
+		}
+	}
+	return e.ID
+}
+
+type advisoryDB struct {
+	entries map[string][]*osvEntry
+	count   int
+}
+
+func advisoryKey(ecosystem, name string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return strings.ToLower(base) + "/" + name
+}
+
+func loadAdvisoryDB(file string) (*advisoryDB, error) {
+	db := &advisoryDB{entries: map[string][]*osvEntry{}}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		err = filepath.WalkDir(file, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(name) != ".json" {
+				return err
+			}
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			return db.add(name, data)
+		})
+		return db, err
+	}
+
+	if filepath.Ext(file) == ".zip" {
+		r, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || filepath.Ext(f.Name) != ".json" {
+				continue
+			}
+			reader, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(reader)
+			reader.Close()
+			if err != nil {
+				return nil, err
+			}
+			if err := db.add(f.Name, data); err != nil {
+				return nil, err
+			}
+		}
+		return db, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return db, db.add(file, data)
+}
+
+func (db *advisoryDB) add(name string, data []byte) error {
+	var entries []*osvEntry
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return fmt.Errorf(`unmarshal advisories "%s": %v`, name, err)
+		}
+	} else {
+		entry := &osvEntry{}
+		if err := json.Unmarshal(trimmed, entry); err != nil {
+			return fmt.Errorf(`unmarshal advisory "%s": %v`, name, err)
+		}
+		entries = append(entries, entry)
+	}
+	for _, entry := range entries {
+		keys := map[string]bool{}
+		for _, affected := range entry.Affected {
+			key := advisoryKey(affected.Package.Ecosystem, affected.Package.Name)
+			if !keys[key] {
+				keys[key] = true
+				db.entries[key] = append(db.entries[key], entry)
+			}
+		}
+		db.count++
+	}
+	return nil
+}
+
+type vulnerability struct {
+	ID        string   `json:"id"`
+	CVE       string   `json:"cve"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Package   string   `json:"package"`
+	Version   string   `json:"version"`
+	Ecosystem string   `json:"ecosystem"`
+	Fixed     string   `json:"fixed,omitempty"`
+	Summary   string   `json:"summary,omitempty"`
+}
+
+func (db *advisoryDB) match(pkg installedPackage) []vulnerability {
+	name, version := pkg.advisoryName(), pkg.advisoryVersion()
+	var vulns []vulnerability
+	for _, entry := range db.entries[advisoryKey(pkg.Ecosystem, name)] {
+		for _, affected := range entry.Affected {
+			if affected.Package.Name != name || !ecosystemMatches(affected.Package.Ecosystem, pkg.Ecosystem) {
+				continue
+			}
+			fixed, ok := affected.affects(pkg.Ecosystem, version)
+			if !ok {
+				continue
+			}
+			vulns = append(vulns, vulnerability{
+				ID:        entry.ID,
+				CVE:       entry.cve(),
+				Aliases:   entry.Aliases,
+				Package:   pkg.Name,
+				Version:   pkg.Version,
+				Ecosystem: affected.Package.Ecosystem,
+				Fixed:     fixed,
+				Summary:   entry.Summary,
+			})
+			break
+		}
+	}
+	return vulns
+}
+
+func ecosystemMatches(advisory, image string) bool {
+	if advisory == image || strings.HasPrefix(advisory, image+":") {
+		return true
+	}
+	advisoryBase, _, advisoryRelease := strings.Cut(advisory, ":")
+	imageBase, _, imageRelease := strings.Cut(image, ":")
+	return advisoryBase == imageBase && (!advisoryRelease || !imageRelease)
+}
+
+func (a *osvAffected) affects(ecosystem, version string) (string, bool) {
+	for _, v := range a.Versions {
+		if v == version {
+			return "", true
+		}
+	}
+
+	for _, r := range a.Ranges {
+		if r.Type != osvRangeEcosystem && r.Type != osvRangeSemver {
+			continue
+		}
+		events := make([]map[string]string, len(r.Events))
+		copy(events, r.Events)
+		sort.SliceStable(events, func(i, j int) bool {
+			return compareEventVersions(ecosystem, eventVersion(events[i]), eventVersion(events[j])) < 0
+		})
+
+		affected, fixed := false, ""
+		for _, event := range events {
+			if v, ok := event[osvEventIntroduced]; ok && (v == "0" || compareVersions(ecosystem, version, v) >= 0) {
+				affected, fixed = true, ""
+			} else if v, ok := event[osvEventFixed]; ok {
+				if compareVersions(ecosystem, version, v) >= 0 {
+					affected = false
+				} else if affected && fixed == "" {
+					fixed = v
+				}
+			} else if v, ok := event[osvEventLastAffected]; ok && compareVersions(ecosystem, version, v) > 0 {
+				affected = false
+			}
+		}
+		if affected {
+			return fixed, true
+		}
+	}
+	return "", false
+}
+
+func eventVersion(event map[string]string) string {
+	for _, key := range []string{osvEventIntroduced, osvEventFixed, osvEventLastAffected} {
+		if v, ok := event[key]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func compareEventVersions(ecosystem, a, b string) int {
+	switch {
+	case a == "0" && b == "0":
+		return 0
+	case a == "0":
+		return -1
+	case b == "0":
+		return 1
+	}
+	return compareVersions(ecosystem, a, b)
+}
@@ -0,0 +1,58 @@
+package action
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAdvisoryMatch(t *testing.T) {
+	db := &advisoryDB{entries: map[string][]*osvEntry{}}
+	advisories := `[
+		{"id": "DSA-5343-1", "aliases": ["CVE-2023-0286"], "affected": [
+			{"package": {"ecosystem": "Debian:11", "name": "openssl"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"fixed": "1.1.1n-0+deb11u4"}, {"introduced": "0"}]}]}
+		]},
+		{"id": "GHSA-xxxx", "aliases": ["CVE-2021-23337"], "affected": [
+			{"package": {"ecosystem": "npm", "name": "lodash"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "4.0.0"}, {"last_affected": "4.17.20"}]}]}
+		]},
+		{"id": "CVE-2020-0001", "affected": [
+			{"package": {"ecosystem": "Debian:10", "name": "openssl"}, "versions": ["1.1.1n-0+deb11u3"]},
+			{"package": {"ecosystem": "Alpine", "name": "openssl"}, "versions": ["3.0.8-r0"]}
+		]}
+	]`
+	if err := db.add("advisories.json", []byte(advisories)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pkg   installedPackage
+		ids   []string
+		fixed string
+	}{
+		{pkg: installedPackage{Name: "libssl1.1", Source: "openssl", Version: "1.1.1n-0+deb11u3", Ecosystem: "Debian:11"}, ids: []string{"DSA-5343-1"}, fixed: "1.1.1n-0+deb11u4"},
+		{pkg: installedPackage{Name: "libssl1.1", Source: "openssl", Version: "1.1.1n-0+deb11u4", Ecosystem: "Debian:11"}},
+		{pkg: installedPackage{Name: "openssl", Version: "1.1.1n-0+deb11u3", Ecosystem: "Debian"}, ids: []string{"DSA-5343-1", "CVE-2020-0001"}, fixed: "1.1.1n-0+deb11u4"},
+		{pkg: installedPackage{Name: "openssl", Version: "3.0.8-r0", Ecosystem: "Alpine:v3.17"}, ids: []string{"CVE-2020-0001"}},
+		{pkg: installedPackage{Name: "lodash", Version: "4.17.20", Ecosystem: "npm"}, ids: []string{"GHSA-xxxx"}},
+		{pkg: installedPackage{Name: "lodash", Version: "4.17.21", Ecosystem: "npm"}},
+		{pkg: installedPackage{Name: "lodash", Version: "3.10.1", Ecosystem: "npm"}},
+	}
+	for _, tt := range tests {
+		vulns := db.match(tt.pkg)
+		ids := []string{}
+		for _, v := range vulns {
+			ids = append(ids, v.ID)
+		}
+		expect, _ := json.Marshal(append([]string{}, tt.ids...))
+		got, _ := json.Marshal(ids)
+		if string(expect) != string(got) {
+			t.Errorf("%s %s %s: expect %s, but got %s", tt.pkg.Ecosystem, tt.pkg.Name, tt.pkg.Version, expect, got)
+			continue
+		}
+		if len(vulns) > 0 && vulns[0].Fixed != tt.fixed {
+			t.Errorf("%s %s %s: expect fixed in %q, but got %q", tt.pkg.Ecosystem, tt.pkg.Name, tt.pkg.Version, tt.fixed, vulns[0].Fixed)
+		}
+		if len(vulns) > 0 && vulns[0].CVE == "" {
+			t.Errorf("%s %s %s: expect cve", tt.pkg.Ecosystem, tt.pkg.Name, tt.pkg.Version)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	osReleaseFile       = "etc/os-release"
+	osReleaseFallback   = "usr/lib/os-release"
+	dpkgStatusFile      = "var/lib/dpkg/status"
+	dpkgStatusDir       = "var/lib/dpkg/status.d"
+	apkInstalledFile    = "lib/apk/db/installed"
+	rpmSQLiteFile       = "var/lib/rpm/rpmdb.sqlite"
+	rpmSQLiteSysimage   = "usr/lib/sysimage/rpm/rpmdb.sqlite"
+	rpmBerkeleyDBFile   = "var/lib/rpm/Packages"
+	rpmNDBSysimage      = "usr/lib/sysimage/rpm/Packages.db"
+	maxPackageDBSize    = 256 << 20
+	rpmTagName          = 1000
+	rpmTagVersion       = 1001
+	rpmTagRelease       = 1002
+	rpmTagEpoch         = 1003
+	rpmTypeInt32        = 4
+	rpmTypeString       = 6
+	rpmHeaderEntrySize  = 16
+	rpmHeaderMaxEntries = 0xffff
+)
+
+type installedPackage struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Source        string `json:"source,omitempty"`
+	SourceVersion string `json:"sourceVersion,omitempty"`
+	Ecosystem     string `json:"ecosystem"`
+}
+
+func (p installedPackage) advisoryName() string {
+	if p.Source != "" {
+		return p.Source
+	}
+	return p.Name
+}
+
+func (p installedPackage) advisoryVersion() string {
+	if p.SourceVersion != "" {
+		return p.SourceVersion
+	}
+	return p.Version
+}
+
+func isPackageDBFile(name string) bool {
+	switch name {
+	case osReleaseFile, osReleaseFallback, dpkgStatusFile, apkInstalledFile,
+		rpmSQLiteFile, rpmSQLiteSysimage, rpmBerkeleyDBFile, rpmNDBSysimage:
+		return true
+	}
+	return path.Dir(name) == dpkgStatusDir
+}
+
+type osRelease struct {
+	ID         string `json:"id"`
+	VersionID  string `json:"versionID,omitempty"`
+	PrettyName string `json:"prettyName,omitempty"`
+}
+
+func parseOSRelease(data []byte) osRelease {
+	r := osRelease{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		switch key {
+		case "ID":
+			r.ID = value
+		case "VERSION_ID":
+			r.VersionID = value
+		case "PRETTY_NAME":
+			r.PrettyName = value
+		}
+	}
+	return r
+}
+
+func (r osRelease) ecosystem() string {
+	major, _, _ := strings.Cut(r.VersionID, ".")
+	withRelease := func(base, release string) string {
+		if release == "" {
+			return base
+		}
+		return base + ":" + release
+	}
+	switch r.ID {
+	case "debian":
+		return withRelease(ecosystemDebian, major)
+	case "ubuntu":
+		return withRelease(ecosystemUbuntu, r.VersionID)
+	case "alpine":
+		parts := strings.SplitN(r.VersionID, ".", 3)
+		if len(parts) < 2 {
+			return ecosystemAlpine
+		}
+		return withRelease(ecosystemAlpine, "v"+parts[0]+"."+parts[1])
+	case "rhel", "centos":
+		return withRelease(ecosystemRedHat, withRelease("enterprise_linux", major))
+	case "rocky":
+		return withRelease(ecosystemRocky, major)
+	case "almalinux":
+		return withRelease(ecosystemAlmaLinux, major)
+	case "opensuse-leap":
+		return withRelease(ecosystemOpenSUSE, withRelease("Leap", r.VersionID))
+	case "sles":
+		return ecosystemSUSE
+	}
+	return ""
+}
+
+func parseControlParagraphs(data []byte, fn func(fields map[string]string)) error {
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxPackageDBSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				fn(fields)
+			}
+			fields = map[string]string{}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	if len(fields) > 0 {
+		fn(fields)
+	}
+	return scanner.Err()
+}
+
+func parseDpkgStatus(data []byte, ecosystem string) ([]installedPackage, error) {
+	var packages []installedPackage
+	err := parseControlParagraphs(data, func(fields map[string]string) {
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			return
+		}
+		if fields["Package"] == "" || fields["Version"] == "" {
+			return
+		}
+		pkg := installedPackage{
+			Name:      fields["Package"],
+			Version:   fields["Version"],
+			Ecosystem: ecosystem,
+		}
+		if source := fields["Source"]; source != "" {
+			name, version, ok := strings.Cut(source, " ")
+			pkg.Source = name
+			if ok {
+				pkg.SourceVersion = strings.Trim(strings.TrimSpace(version), "()")
+			}
+		}
+		packages = append(packages, pkg)
+	})
+	return packages, err
+}
+
+func parseApkInstalled(data []byte, ecosystem string) ([]installedPackage, error) {
+	var packages []installedPackage
+	var pkg *installedPackage
+	finish := func() {
+		if pkg != nil && pkg.Name != "" && pkg.Version != "" {
+			packages = append(packages, *pkg)
+		}
+		pkg = nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxPackageDBSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			finish()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		if pkg == nil {
+			pkg = &installedPackage{Ecosystem: ecosystem}
+		}
+		switch line[0] {
+		case 'P':
+			pkg.Name = line[2:]
+		case 'V':
+			pkg.Version = line[2:]
+		case 'o':
+			pkg.Source = line[2:]
+		}
+	}
+	finish()
+	return packages, scanner.Err()
+}
+
+func parseRPMHeader(blob []byte, ecosystem string) (installedPackage, error) {
+	pkg := installedPackage{Ecosystem: ecosystem}
+	if len(blob) < 8 {
+		return pkg, fmt.Errorf("rpm header too short")
+	}
+	il := int(binary.BigEndian.Uint32(blob[0:4]))
+	dl := int(binary.BigEndian.Uint32(blob[4:8]))
+	dataStart := 8 + il*rpmHeaderEntrySize
+	if il > rpmHeaderMaxEntries || dataStart+dl > len(blob) {
+		return pkg, fmt.Errorf("invalid rpm header with %d entries and %d bytes data", il, dl)
+	}
+	store := blob[dataStart : dataStart+dl]
+
+	var version, release, epoch string
+	for i := 0; i < il; i++ {
+		entry := blob[8+i*rpmHeaderEntrySize:]
+		tag := binary.BigEndian.Uint32(entry[0:4])
+		typ := binary.BigEndian.Uint32(entry[4:8])
+		offset := int(binary.BigEndian.Uint32(entry[8:12]))
+		if offset < 0 || offset >= len(store) {
+			continue
+		}
+		var value string
+		switch typ {
+		case rpmTypeString:
+			end := bytes.IndexByte(store[offset:], 0)
+			if end < 0 {
+				continue
+			}
+			value = string(store[offset : offset+end])
+		case rpmTypeInt32:
+			if offset+4 > len(store) {
+				continue
+			}
+			value = strconv.FormatUint(uint64(binary.BigEndian.Uint32(store[offset:])), 10)
+		default:
+			continue
+		}
+		switch tag {
+		case rpmTagName:
+			pkg.Name = value
+		case rpmTagVersion:
+			version = value
+		case rpmTagRelease:
+			release = value
+		case rpmTagEpoch:
+			epoch = value
+		}
+	}
+	if pkg.Name == "" || version == "" {
+		return pkg, fmt.Errorf("rpm header without name or version")
+	}
+	pkg.Version = version
+	if release != "" {
+		pkg.Version += "-" + release
+	}
+	if epoch != "" && epoch != "0" {
+		pkg.Version = epoch + ":" + pkg.Version
+	}
+	return pkg, nil
+}
+
+func parseRPMSQLite(data []byte, ecosystem string) ([]installedPackage, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+	var packages []installedPackage
+	err = db.scanTable("Packages", func(values []interface{}) error {
+		if len(values) < 2 {
+			return nil
+		}
+		blob, ok := values[1].([]byte)
+		if !ok {
+			return nil
+		}
+		pkg, err := parseRPMHeader(blob, ecosystem)
+		if err != nil {
+			return err
+		}
+		packages = append(packages, pkg)
+		return nil
+	})
+	return packages, err
+}
+
+type packageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers url.Values
+}
+
+func parsePackageURL(purl string) (packageURL, bool) {
+	p := packageURL{}
+	if !strings.HasPrefix(purl, "pkg:") {
+		return p, false
+	}
+	rest := strings.TrimPrefix(purl, "pkg:")
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		p.Qualifiers, _ = url.ParseQuery(rest[i+1:])
+		rest = rest[:i]
+	}
+	if i := strings.LastIndexByte(rest, '@'); i >= 0 {
+		p.Version, _ = url.PathUnescape(rest[i+1:])
+		rest = rest[:i]
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(parts) < 2 {
+		return p, false
+	}
+	p.Type = strings.ToLower(parts[0])
+	for i, part := range parts[1:] {
+		parts[i+1], _ = url.PathUnescape(part)
+	}
+	p.Name = parts[len(parts)-1]
+	p.Namespace = strings.Join(parts[1:len(parts)-1], "/")
+	return p, p.Name != "" && p.Version != ""
+}
+
+var purlEcosystems = map[string]string{
+	"npm":      "npm",
+	"pypi":     "PyPI",
+	"golang":   "Go",
+	"maven":    "Maven",
+	"cargo":    "crates.io",
+	"gem":      "RubyGems",
+	"nuget":    "NuGet",
+	"composer": "Packagist",
+	"hex":      "Hex",
+	"pub":      "Pub",
+}
+
+func (p packageURL) installedPackage() (installedPackage, bool) {
+	pkg := installedPackage{Name: p.Name, Version: p.Version}
+	switch p.Type {
+	case "deb", "apk", "rpm":
+		distro := p.Qualifiers.Get("distro")
+		id, version, ok := strings.Cut(distro, "-")
+		if !ok && id != "" && id[0] >= '0' && id[0] <= '9' {
+			id, version = "", id
+		}
+		if id == "" {
+			id = p.Namespace
+		}
+		switch id {
+		case "redhat":
+			id = "rhel"
+		case "opensuse":
+			id = "opensuse-leap"
+		}
+		pkg.Ecosystem = osRelease{ID: id, VersionID: version}.ecosystem()
+		if upstream := p.Qualifiers.Get("upstream"); upstream != "" && p.Type != "rpm" {
+			name, version, _ := strings.Cut(upstream, "@")
+			if name != p.Name {
+				pkg.Source = name
+			}
+			pkg.SourceVersion = version
+		}
+		if epoch := p.Qualifiers.Get("epoch"); epoch != "" && epoch != "0" && !strings.Contains(pkg.Version, ":") {
+			pkg.Version = epoch + ":" + pkg.Version
+		}
+	case "golang":
+		pkg.Ecosystem = purlEcosystems[p.Type]
+		pkg.Name = path.Join(p.Namespace, p.Name)
+	case "maven":
+		pkg.Ecosystem = purlEcosystems[p.Type]
+		pkg.Name = p.Namespace + ":" + p.Name
+	case "npm", "composer":
+		pkg.Ecosystem = purlEcosystems[p.Type]
+		if p.Namespace != "" {
+			pkg.Name = p.Namespace + "/" + p.Name
+		}
+	default:
+		pkg.Ecosystem = purlEcosystems[p.Type]
+	}
+	return pkg, pkg.Ecosystem != ""
+}
@@ -0,0 +1,177 @@
+package action
+
+import (
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseDpkgStatus(t *testing.T) {
+	status := `Package: libssl1.1
+Status: install ok installed
+Source: openssl (1.1.1n-0+deb11u3)
+Version: 1.1.1n-0+deb11u3
+Description: Secure Sockets Layer toolkit
+ Version: 9.9.9 in a continuation line
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: zlib1g
+Status: install ok installed
+Source: zlib
+Version: 1:1.2.11.dfsg-2+deb11u2
+
+Package: no-version
+Status: install ok installed
+`
+	packages, err := parseDpkgStatus([]byte(status), "Debian:11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []installedPackage{
+		{Name: "libssl1.1", Version: "1.1.1n-0+deb11u3", Source: "openssl", SourceVersion: "1.1.1n-0+deb11u3", Ecosystem: "Debian:11"},
+		{Name: "zlib1g", Version: "1:1.2.11.dfsg-2+deb11u2", Source: "zlib", Ecosystem: "Debian:11"},
+	}
+	if !reflect.DeepEqual(packages, expect) {
+		t.Errorf("expect %+v, but got %+v", expect, packages)
+	}
+}
+
+func TestParseApkInstalled(t *testing.T) {
+	installed := "C:Q1abc=\nP:libcrypto3\nV:3.0.8-r0\no:openssl\n\nP:musl\nV:1.2.3-r4\n\nP:broken\n"
+	packages, err := parseApkInstalled([]byte(installed), "Alpine:v3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []installedPackage{
+		{Name: "libcrypto3", Version: "3.0.8-r0", Source: "openssl", Ecosystem: "Alpine:v3.17"},
+		{Name: "musl", Version: "1.2.3-r4", Ecosystem: "Alpine:v3.17"},
+	}
+	if !reflect.DeepEqual(packages, expect) {
+		t.Errorf("expect %+v, but got %+v", expect, packages)
+	}
+}
+
+func rpmHeader(tags ...interface{}) []byte {
+	var entries, store []byte
+	for i := 0; i < len(tags); i += 2 {
+		entry := make([]byte, rpmHeaderEntrySize)
+		binary.BigEndian.PutUint32(entry, uint32(tags[i].(int)))
+		switch v := tags[i+1].(type) {
+		case string:
+			binary.BigEndian.PutUint32(entry[4:], rpmTypeString)
+			binary.BigEndian.PutUint32(entry[8:], uint32(len(store)))
+			store = append(append(store, v...), 0)
+		case uint32:
+			for len(store)%4 != 0 {
+				store = append(store, 0)
+			}
+			binary.BigEndian.PutUint32(entry[4:], rpmTypeInt32)
+			binary.BigEndian.PutUint32(entry[8:], uint32(len(store)))
+			store = binary.BigEndian.AppendUint32(store, v)
+		}
+		binary.BigEndian.PutUint32(entry[12:], 1)
+		entries = append(entries, entry...)
+	}
+	header := binary.BigEndian.AppendUint32(nil, uint32(len(entries)/rpmHeaderEntrySize))
+	header = binary.BigEndian.AppendUint32(header, uint32(len(store)))
+	return append(append(header, entries...), store...)
+}
+
+func TestParseRPMHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		blob    []byte
+		version string
+		err     bool
+	}{
+		{name: "name version release", blob: rpmHeader(rpmTagName, "bash", rpmTagVersion, "5.1.8", rpmTagRelease, "6.el9"), version: "5.1.8-6.el9"},
+		{name: "epoch", blob: rpmHeader(rpmTagName, "bash", rpmTagVersion, "5.1.8", rpmTagRelease, "6.el9", rpmTagEpoch, uint32(1)), version: "1:5.1.8-6.el9"},
+		{name: "zero epoch", blob: rpmHeader(rpmTagName, "bash", rpmTagEpoch, uint32(0), rpmTagVersion, "5.1.8"), version: "5.1.8"},
+		{name: "no version", blob: rpmHeader(rpmTagName, "bash"), err: true},
+		{name: "too short", blob: []byte{0, 0, 0}, err: true},
+		{name: "truncated store", blob: rpmHeader(rpmTagName, "bash", rpmTagVersion, "5.1.8")[:30], err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := parseRPMHeader(tt.blob, "Red Hat:enterprise_linux:9")
+			if (err != nil) != tt.err {
+				t.Fatalf("expect error %v, but got %v", tt.err, err)
+			}
+			if err == nil && (pkg.Name != "bash" || pkg.Version != tt.version) {
+				t.Errorf("expect bash %s, but got %s %s", tt.version, pkg.Name, pkg.Version)
+			}
+		})
+	}
+}
+
+func TestParseRPMSQLite(t *testing.T) {
+	data, err := os.ReadFile("testdata/rpmdb.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages, err := parseRPMSQLite(data, "Rocky Linux:9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 40 {
+		t.Fatalf("expect 40 packages, but got %d", len(packages))
+	}
+	for _, expect := range []installedPackage{
+		{Name: "pkg00", Version: "1.0-1.el9", Ecosystem: "Rocky Linux:9"},
+		{Name: "pkg07", Version: "2:1.7-2.el9", Ecosystem: "Rocky Linux:9"},
+		{Name: "pkg08", Version: "1.8-3.el9", Ecosystem: "Rocky Linux:9"},
+		{Name: "pkg13", Version: "1.13-2.el9", Ecosystem: "Rocky Linux:9"},
+		{Name: "pkg39", Version: "1.39-1.el9", Ecosystem: "Rocky Linux:9"},
+	} {
+		found := false
+		for _, pkg := range packages {
+			found = found || pkg == expect
+		}
+		if !found {
+			t.Errorf("expect %+v in the packages", expect)
+		}
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		purl string
+		ok   bool
+		pkg  installedPackage
+	}{
+		{purl: "pkg:deb/debian/libssl1.1@1.1.1n-0%2Bdeb11u3?arch=amd64&upstream=openssl%401.1.1n-0%2Bdeb11u3&distro=debian-11",
+			ok: true, pkg: installedPackage{Name: "libssl1.1", Version: "1.1.1n-0+deb11u3", Source: "openssl", SourceVersion: "1.1.1n-0+deb11u3", Ecosystem: "Debian:11"}},
+		{purl: "pkg:apk/alpine/musl@1.2.3-r4?arch=x86_64&distro=3.17.2",
+			ok: true, pkg: installedPackage{Name: "musl", Version: "1.2.3-r4", Ecosystem: "Alpine:v3.17"}},
+		{purl: "pkg:rpm/redhat/bash@5.1.8-6.el9?epoch=1&distro=redhat-9.2",
+			ok: true, pkg: installedPackage{Name: "bash", Version: "1:5.1.8-6.el9", Ecosystem: "Red Hat:enterprise_linux:9"}},
+		{purl: "pkg:golang/github.com/gin-gonic/gin@v1.9.0",
+			ok: true, pkg: installedPackage{Name: "github.com/gin-gonic/gin", Version: "v1.9.0", Ecosystem: "Go"}},
+		{purl: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+			ok: true, pkg: installedPackage{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Ecosystem: "Maven"}},
+		{purl: "pkg:npm/%40babel/core@7.21.0#lib",
+			ok: true, pkg: installedPackage{Name: "@babel/core", Version: "7.21.0", Ecosystem: "npm"}},
+		{purl: "pkg:pypi/requests@2.28.2",
+			ok: true, pkg: installedPackage{Name: "requests", Version: "2.28.2", Ecosystem: "PyPI"}},
+		{purl: "pkg:generic/thing@1.0"},
+		{purl: "pkg:npm/lodash"},
+		{purl: "npm/lodash@4.17.21"},
+	}
+	for _, tt := range tests {
+		p, ok := parsePackageURL(tt.purl)
+		if ok {
+			var pkg installedPackage
+			pkg, ok = p.installedPackage()
+			if ok && pkg != tt.pkg {
+				t.Errorf("%s: expect %+v, but got %+v", tt.purl, tt.pkg, pkg)
+			}
+		}
+		if ok != tt.ok {
+			t.Errorf("%s: expect %v, but got %v", tt.purl, tt.ok, ok)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package action
+
+import (
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"strings"
+)
+
+const (
+	changeDeleteManifest = "delete-manifest"
+	changeUntag          = "untag"
+	changePutManifest    = "put-manifest"
+	changeUploadBlob     = "upload-blob"
+	changeMountBlob      = "mount-blob"
+	changeKeepManifest   = "keep"
+	permissionGranted    = "granted"
+	permissionUnchecked  = "unchecked"
+)
+
+type change struct {
+	Action     string   `json:"action"`
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+func (c change) Header() []string {
+	return []string{"ACTION", "REPOSITORY", "DIGEST", "TAGS"}
+}
+
+func (c *change) Column() []string {
+	tags := strings.Join(c.Tags, ",")
+	if tags == "" {
+		tags = "-"
+	}
+	return []string{c.Action, c.Repository, c.Digest, tags}
+}
+
+type changePlan struct {
+	DryRun     bool     `json:"dryRun"`
+	Permission string   `json:"permission,omitempty"`
+	Changes    []change `json:"changes"`
+}
+
+func (p *changePlan) Add(c change) {
+	p.Changes = append(p.Changes, c)
+}
+
+func (p *changePlan) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, p)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, change{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, c := range p.Changes {
+			if err := w.Write(c.Column()...); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if p.Permission == permissionUnchecked {
+			_, err := fmt.Fprintln(opts.StdOut, "\nPermission: not checked, the registry does not issue scoped tokens")
+			return err
+		}
+		return nil
+	}
+	return errors.ErrUnknownOutput
+}
+
+func (p *changePlan) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.Action != changeKeepManifest {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *changePlan) CheckPermission(opts *option.Options, cli *client.Client, repo string, action client.Action) error {
+	granted, checked := cli.CheckAccess(repo, action)
+	if !checked {
+		p.Permission = permissionUnchecked
+		return nil
+	}
+	if !granted {
+		opts.WriteDebug(fmt.Sprintf(`check %s permission of "%s"`, action, repo), errors.ErrPermissionDenied)
+		return fmt.Errorf(`%w: %s "%s"`, errors.ErrPermissionDenied, action, repo)
+	}
+	p.Permission = permissionGranted
+	return nil
+}
@@ -0,0 +1,297 @@
+package action
+
+import (
+	"fmt"
+	"regexp"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	pruneActionKeep   = "keep"
+	pruneActionDelete = "delete"
+)
+
+type retentionPolicy struct {
+	keepLast  int
+	keepTag   *regexp.Regexp
+	olderThan time.Duration
+	now       time.Time
+}
+
+type pruneItem struct {
+	Action  string
+	Digest  string
+	Tags    []string
+	Created *time.Time
+	Size    *int64
+
+	children []string
+}
+
+func Prune(opts *option.Options) error {
+	policy, err := newRetentionPolicy(opts)
+	if err != nil {
+		opts.WriteDebug("init retention policy", err)
+		return err
+	}
+
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+
+	_, tags, err := getAllTags(opts, cli)
+	if err != nil {
+		opts.WriteDebug("get tags", err)
+		return err
+	}
+
+	repo, err := cli.NewRepository(opts.Repositiory, client.DeleteAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init mainifest service", err)
+		return err
+	}
+
+	items := policy.evaluate(tags)
+	plan := &changePlan{DryRun: opts.DryRun}
+	for _, item := range items {
+		action := changeKeepManifest
+		if item.Action == pruneActionDelete {
+			action = changeDeleteManifest
+		}
+		plan.Add(change{
+			Action:     action,
+			Repository: opts.Repositiory,
+			Digest:     item.Digest,
+			Tags:       item.Tags,
+		})
+	}
+	if opts.DryRun && plan.HasChanges() {
+		if err := plan.CheckPermission(opts, cli, opts.Repositiory, client.DeleteAction); err != nil {
+			return err
+		}
+	}
+	if err := plan.Output(opts); err != nil {
+		opts.WriteDebug("output prune plan", err)
+		return err
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	for _, item := range items {
+		if item.Action != pruneActionDelete {
+			continue
+		}
+		if err := deleteManifest(opts, manifestService, digest.Digest(item.Digest)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRetentionPolicy(opts *option.Options) (*retentionPolicy, error) {
+	if opts.KeepLast <= 0 && opts.KeepTag == "" && opts.OlderThan <= 0 {
+		return nil, errors.ErrNeedRetentionRule
+	}
+	p := &retentionPolicy{
+		keepLast:  opts.KeepLast,
+		olderThan: opts.OlderThan,
+		now:       time.Now(),
+	}
+	if opts.KeepTag != "" {
+		re, err := regexp.Compile(opts.KeepTag)
+		if err != nil {
+			return nil, fmt.Errorf(`compile tag pattern "%s" error: %v`, opts.KeepTag, err)
+		}
+		p.keepTag = re
+	}
+	return p, nil
+}
+
+func (p *retentionPolicy) evaluate(tags []tagInfo) []pruneItem {
+	var imageTags, artifactTags []tagInfo
+	for _, tag := range tags {
+		if artifactSubject(tag.Tag) != "" {
+			artifactTags = append(artifactTags, tag)
+		} else {
+			imageTags = append(imageTags, tag)
+		}
+	}
+	tops, children := groupPruneItems(imageTags)
+	artifacts, artifactChildren := groupPruneItems(artifactTags)
+	for dgst, child := range artifactChildren {
+		if children[dgst] == nil {
+			children[dgst] = child
+		}
+	}
+
+	sort.SliceStable(tops, func(i, j int) bool {
+		if tops[i].Created == nil {
+			return false
+		}
+		if tops[j].Created == nil {
+			return true
+		}
+		return tops[i].Created.After(*tops[j].Created)
+	})
+
+	kept := map[string]bool{}
+	for i, top := range tops {
+		if p.keep(i, top) {
+			markKept(kept, top)
+		}
+	}
+	for _, artifact := range artifacts {
+		for _, tag := range artifact.Tags {
+			if kept[artifactSubject(tag)] || !containsDigest(tops, children, artifactSubject(tag)) {
+				markKept(kept, artifact)
+				break
+			}
+		}
+	}
+
+	all := append(tops, artifacts...)
+	isTop := map[string]bool{}
+	for _, top := range all {
+		isTop[top.Digest] = true
+	}
+	var items []pruneItem
+	planned := map[string]bool{}
+	for _, top := range all {
+		if kept[top.Digest] {
+			top.Action = pruneActionKeep
+			items = append(items, *top)
+			continue
+		}
+		if planned[top.Digest] {
+			continue
+		}
+		top.Action = pruneActionDelete
+		items = append(items, *top)
+		planned[top.Digest] = true
+		for _, child := range top.children {
+			if kept[child] || planned[child] || isTop[child] {
+				continue
+			}
+			item := *children[child]
+			item.Action = pruneActionDelete
+			items = append(items, item)
+			planned[child] = true
+		}
+	}
+	return items
+}
+
+func groupPruneItems(tags []tagInfo) ([]*pruneItem, map[string]*pruneItem) {
+	var tops []*pruneItem
+	topByDigest := map[string]*pruneItem{}
+	childByDigest := map[string]*pruneItem{}
+	topOfTag := map[string]string{}
+	for _, tag := range tags {
+		if tag.ListDigest == "" {
+			topOfTag[tag.Tag] = tag.Digest
+			continue
+		}
+		topOfTag[tag.Tag] = tag.ListDigest
+		if childByDigest[tag.Digest] == nil {
+			childByDigest[tag.Digest] = &pruneItem{
+				Digest:  tag.Digest,
+				Created: tag.Created,
+				Size:    tag.Size,
+			}
+		}
+	}
+	for _, tag := range tags {
+		dgst := topOfTag[tag.Tag]
+		top := topByDigest[dgst]
+		if top == nil {
+			top = &pruneItem{Digest: dgst}
+			topByDigest[dgst] = top
+			tops = append(tops, top)
+		}
+		if !containsString(top.Tags, tag.Tag) {
+			top.Tags = append(top.Tags, tag.Tag)
+		}
+		if tag.Created != nil && (top.Created == nil || tag.Created.After(*top.Created)) {
+			top.Created = tag.Created
+		}
+		if tag.ListDigest != "" {
+			if !containsString(top.children, tag.Digest) {
+				top.children = append(top.children, tag.Digest)
+				top.Size = addSize(top.Size, tag.Size)
+			}
+		} else if top.Size == nil {
+			top.Size = tag.Size
+		}
+	}
+	return tops, childByDigest
+}
+
+func markKept(kept map[string]bool, item *pruneItem) {
+	kept[item.Digest] = true
+	for _, child := range item.children {
+		kept[child] = true
+	}
+}
+
+func containsDigest(tops []*pruneItem, children map[string]*pruneItem, dgst string) bool {
+	if children[dgst] != nil {
+		return true
+	}
+	for _, top := range tops {
+		if top.Digest == dgst {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *retentionPolicy) keep(rank int, item *pruneItem) bool {
+	if p.keepTag != nil {
+		for _, tag := range item.Tags {
+			if p.keepTag.MatchString(tag) {
+				return true
+			}
+		}
+	}
+	if rank < p.keepLast {
+		return true
+	}
+	if p.olderThan > 0 {
+		return item.Created == nil || item.Created.After(p.now.Add(-p.olderThan))
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func addSize(a, b *int64) *int64 {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	sum := *a + *b
+	return &sum
+}
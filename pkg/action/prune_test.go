@@ -0,0 +1,111 @@
+package action
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyEvaluate(t *testing.T) {
+	now := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	days := func(n int) *time.Time {
+		t := now.Add(-time.Duration(n) * 24 * time.Hour)
+		return &t
+	}
+	tags := []tagInfo{
+		{Tag: "v1.0", Digest: "sha256:a", Created: days(30)},
+		{Tag: "v1.1", Digest: "sha256:b", Created: days(20)},
+		{Tag: "dev-1", Digest: "sha256:c", Created: days(10)},
+		{Tag: "dev-2", Digest: "sha256:d", Created: days(5)},
+		{Tag: "dev-3", Digest: "sha256:d", Created: days(5)},
+		{Tag: "multi-old", Digest: "sha256:e", ListDigest: "sha256:l1", Created: days(40)},
+		{Tag: "multi-old", Digest: "sha256:f", ListDigest: "sha256:l1", Created: days(40)},
+		{Tag: "multi-new", Digest: "sha256:e", ListDigest: "sha256:l2", Created: days(1)},
+		{Tag: "multi-new", Digest: "sha256:g", ListDigest: "sha256:l2", Created: days(1)},
+	}
+	for _, c := range []struct {
+		name   string
+		policy retentionPolicy
+		delete []string
+	}{
+		{
+			name:   "keep last",
+			policy: retentionPolicy{keepLast: 2, now: now},
+			delete: []string{"sha256:c", "sha256:b", "sha256:a", "sha256:l1", "sha256:f"},
+		},
+		{
+			name:   "keep tag",
+			policy: retentionPolicy{keepTag: regexp.MustCompile(`^v|^multi`), now: now},
+			delete: []string{"sha256:d", "sha256:c"},
+		},
+		{
+			name:   "older than",
+			policy: retentionPolicy{olderThan: 15 * 24 * time.Hour, now: now},
+			delete: []string{"sha256:b", "sha256:a", "sha256:l1", "sha256:f"},
+		},
+		{
+			name:   "combined",
+			policy: retentionPolicy{keepLast: 1, keepTag: regexp.MustCompile(`^v`), olderThan: 7 * 24 * time.Hour, now: now},
+			delete: []string{"sha256:c", "sha256:l1", "sha256:f"},
+		},
+	} {
+		var deleted []string
+		for _, item := range c.policy.evaluate(tags) {
+			if item.Action == pruneActionDelete {
+				deleted = append(deleted, item.Digest)
+			}
+		}
+		if len(deleted) != len(c.delete) {
+			t.Errorf("%s: expect delete %v, but got %v", c.name, c.delete, deleted)
+			continue
+		}
+		for i := range deleted {
+			if deleted[i] != c.delete[i] {
+				t.Errorf("%s: expect delete %v, but got %v", c.name, c.delete, deleted)
+				break
+			}
+		}
+	}
+}
+
+func TestRetentionPolicyEvaluateArtifacts(t *testing.T) {
+	now := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	days := func(n int) *time.Time {
+		t := now.Add(-time.Duration(n) * 24 * time.Hour)
+		return &t
+	}
+	const (
+		oldImage = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		newImage = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	tags := []tagInfo{
+		{Tag: "v1", Digest: oldImage, Created: days(30)},
+		{Tag: "v2", Digest: newImage, Created: days(10)},
+		{Tag: "sha256-1111111111111111111111111111111111111111111111111111111111111111.sig", Digest: "sha256:s1", Created: days(1)},
+		{Tag: "sha256-1111111111111111111111111111111111111111111111111111111111111111.att", Digest: "sha256:a1", Created: days(1)},
+		{Tag: "sha256-2222222222222222222222222222222222222222222222222222222222222222.sig", Digest: "sha256:s2", Created: days(1)},
+		{Tag: "sha256-3333333333333333333333333333333333333333333333333333333333333333.sig", Digest: "sha256:s3", Created: days(1)},
+		{Tag: "multi", Digest: "sha256:c1", ListDigest: "sha256:l1", Created: days(40)},
+		{Tag: "multi", Digest: newImage, ListDigest: "sha256:l1", Created: days(40)},
+	}
+	policy := retentionPolicy{keepLast: 1, now: now}
+
+	actions := map[string]string{}
+	for _, item := range policy.evaluate(tags) {
+		actions[item.Digest] = item.Action
+	}
+	for dgst, action := range map[string]string{
+		newImage:    pruneActionKeep,
+		"sha256:s2": pruneActionKeep,
+		"sha256:s3": pruneActionKeep,
+		oldImage:    pruneActionDelete,
+		"sha256:s1": pruneActionDelete,
+		"sha256:a1": pruneActionDelete,
+		"sha256:l1": pruneActionDelete,
+		"sha256:c1": pruneActionDelete,
+	} {
+		if actions[dgst] != action {
+			t.Errorf("%s: expect %s, but got %s", dgst, action, actions[dgst])
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/ocischema"
+	"github.com/distribution/distribution/manifest/schema2"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Pull(opts *option.Options) error {
+	var w layoutWriter
+	var err error
+	switch {
+	case opts.OCILayout != "":
+		w, err = newDirLayoutWriter(opts.OCILayout)
+	case opts.Archive != "":
+		w, err = newTarLayoutWriter(opts.Archive)
+	default:
+		return errors.ErrNeedPullDestination
+	}
+	if err != nil {
+		opts.WriteDebug("init oci layout writer", err)
+		return err
+	}
+
+	if err := pull(opts, w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		opts.WriteDebug("close oci layout writer", err)
+		return err
+	}
+	return nil
+}
+
+func pull(opts *option.Options, w layoutWriter) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+
+	p := &puller{
+		opts:            opts,
+		repo:            repo,
+		manifestService: manifestService,
+		writer:          w,
+	}
+	desc, err := p.pullManifest(man, opts.Digest)
+	if err != nil {
+		return err
+	}
+	if opts.Tag != "" {
+		desc.Annotations = map[string]string{
+			ocispec.AnnotationRefName: opts.Tag,
+		}
+	}
+
+	index, err := w.ReadIndex()
+	if err != nil {
+		opts.WriteDebug("read oci layout index", err)
+		return err
+	}
+	if index == nil {
+		index = &ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+		}
+	}
+	manifests := []ocispec.Descriptor{}
+	for _, m := range index.Manifests {
+		name := m.Annotations[ocispec.AnnotationRefName]
+		if name == opts.Tag && (name != "" || m.Digest == desc.Digest) {
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+	index.Manifests = append(manifests, desc)
+	if err := w.WriteIndex(index); err != nil {
+		opts.WriteDebug("write oci layout index", err)
+		return err
+	}
+	return nil
+}
+
+type puller struct {
+	opts            *option.Options
+	repo            distribution.Repository
+	manifestService distribution.ManifestService
+	writer          layoutWriter
+}
+
+func (p *puller) pullManifest(man distribution.Manifest, dgst digest.Digest) (ocispec.Descriptor, error) {
+	mediaType, payload, err := man.Payload()
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`get payload of manifest "%s"`, dgst), err)
+		return ocispec.Descriptor{}, err
+	}
+
+	switch realMan := man.(type) {
+	case *manifestlist.DeserializedManifestList:
+		for _, ref := range realMan.Manifests {
+			child, err := p.manifestService.Get(p.opts.Ctx, ref.Digest)
+			if err != nil {
+				p.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, ref.Digest), err)
+				return ocispec.Descriptor{}, err
+			}
+			if _, err := p.pullManifest(child, ref.Digest); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+		}
+	case *schema2.DeserializedManifest, *ocischema.DeserializedManifest:
+		for _, desc := range man.References() {
+			if err := p.pullBlob(desc); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+		}
+	default:
+		return ocispec.Descriptor{}, errors.ErrUnknownManifest
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(payload),
+		Size:      int64(len(payload)),
+	}
+	if dgst != "" && desc.Digest != dgst {
+		p.opts.WriteDebug(fmt.Sprintf(`manifest "%s" has digest "%s"`, dgst, desc.Digest), errors.ErrDigestMismatch)
+		return ocispec.Descriptor{}, errors.ErrDigestMismatch
+	}
+	if !p.writer.HasBlob(desc.Digest) {
+		if err := p.writer.WriteBlob(desc.Digest, desc.Size, bytes.NewReader(payload)); err != nil {
+			p.opts.WriteDebug(fmt.Sprintf(`write manifest "%s"`, desc.Digest), err)
+			return ocispec.Descriptor{}, err
+		}
+	}
+	return desc, nil
+}
+
+func (p *puller) pullBlob(desc distribution.Descriptor) error {
+	if p.writer.HasBlob(desc.Digest) {
+		p.opts.WriteDebug(fmt.Sprintf(`blob "%s" exists, skip`, desc.Digest), nil)
+		return nil
+	}
+	reader, err := p.repo.Blobs(p.opts.Ctx).Open(p.opts.Ctx, desc.Digest)
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`open blob "%s"`, desc.Digest), err)
+		return err
+	}
+	defer reader.Close()
+
+	if err := p.writer.WriteBlob(desc.Digest, desc.Size, reader); err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`write blob "%s"`, desc.Digest), err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"strings"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/ocischema"
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const dockerArchiveManifestFile = "manifest.json"
+
+type dockerArchiveManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+func Push(opts *option.Options) error {
+	var r layoutReader
+	var err error
+	switch {
+	case opts.OCILayout != "":
+		r, err = newDirLayoutReader(opts.OCILayout)
+	case opts.Archive != "":
+		r, err = newTarLayoutReader(opts.Archive)
+	default:
+		return errors.ErrNeedPushSource
+	}
+	if err != nil {
+		opts.WriteDebug("init oci layout reader", err)
+		return err
+	}
+	defer r.Close()
+
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction, client.PushAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	p := &pusher{
+		opts:            opts,
+		cli:             cli,
+		repo:            repo,
+		manifestService: manifestService,
+		reader:          r,
+		pushed:          map[digest.Digest]bool{},
+	}
+	if opts.DryRun {
+		p.plan = &changePlan{DryRun: true}
+		if err := p.plan.CheckPermission(opts, cli, opts.Repositiory, client.PushAction); err != nil {
+			return err
+		}
+	}
+
+	_, err = readLayoutFile(r, ocispec.ImageLayoutFile)
+	switch {
+	case err == nil:
+		err = p.pushLayout()
+	case os.IsNotExist(err):
+		err = p.pushDockerArchive()
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.plan != nil {
+		return p.plan.Output(opts)
+	}
+	return nil
+}
+
+type pusher struct {
+	opts            *option.Options
+	cli             *client.Client
+	repo            distribution.Repository
+	manifestService distribution.ManifestService
+	reader          layoutReader
+	plan            *changePlan
+	pushed          map[digest.Digest]bool
+}
+
+func (p *pusher) pushLayout() error {
+	data, err := readLayoutFile(p.reader, layoutIndexFile)
+	if err != nil {
+		p.opts.WriteDebug("read oci layout index", err)
+		return err
+	}
+	index := ocispec.Index{}
+	if err := json.Unmarshal(data, &index); err != nil {
+		p.opts.WriteDebug("unmarshal oci layout index", err)
+		return err
+	}
+
+	var target *ocispec.Descriptor
+	if len(index.Manifests) == 1 {
+		target = &index.Manifests[0]
+	} else {
+		for i, desc := range index.Manifests {
+			if matchTag(desc.Annotations[ocispec.AnnotationRefName], p.opts.Tag) {
+				target = &index.Manifests[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		return errors.ErrNeedSourceImage
+	}
+
+	return p.pushManifest(distribution.Descriptor{
+		MediaType: target.MediaType,
+		Digest:    target.Digest,
+		Size:      target.Size,
+	}, p.opts.Tag)
+}
+
+func (p *pusher) pushDockerArchive() error {
+	data, err := readLayoutFile(p.reader, dockerArchiveManifestFile)
+	if err != nil {
+		p.opts.WriteDebug("read docker archive manifest", err)
+		return err
+	}
+	var entries []dockerArchiveManifest
+	if err := json.Unmarshal(data, &entries); err != nil {
+		p.opts.WriteDebug("unmarshal docker archive manifest", err)
+		return err
+	}
+
+	var target *dockerArchiveManifest
+	if len(entries) == 1 {
+		target = &entries[0]
+	} else {
+		for i, entry := range entries {
+			for _, repoTag := range entry.RepoTags {
+				if matchTag(repoTag, p.opts.Tag) {
+					target = &entries[i]
+					break
+				}
+			}
+			if target != nil {
+				break
+			}
+		}
+	}
+	if target == nil {
+		return errors.ErrNeedSourceImage
+	}
+
+	config, err := readLayoutFile(p.reader, target.Config)
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`read config "%s"`, target.Config), err)
+		return err
+	}
+	m := ocischema.Manifest{
+		Versioned: manifest.Versioned{
+			SchemaVersion: 2,
+			MediaType:     ocispec.MediaTypeImageManifest,
+		},
+		Config: distribution.Descriptor{
+			MediaType: ocispec.MediaTypeImageConfig,
+			Digest:    digest.FromBytes(config),
+			Size:      int64(len(config)),
+		},
+	}
+	if err := p.pushBlob(m.Config, target.Config); err != nil {
+		return err
+	}
+	for _, layer := range target.Layers {
+		desc, err := p.describeLayer(layer)
+		if err != nil {
+			p.opts.WriteDebug(fmt.Sprintf(`describe layer "%s"`, layer), err)
+			return err
+		}
+		if err := p.pushBlob(desc, layer); err != nil {
+			return err
+		}
+		m.Layers = append(m.Layers, desc)
+	}
+
+	man, err := ocischema.FromStruct(m)
+	if err != nil {
+		return err
+	}
+	_, payload, err := man.Payload()
+	if err != nil {
+		return err
+	}
+	return p.putManifest(man, digest.FromBytes(payload), p.opts.Tag)
+}
+
+func (p *pusher) describeLayer(name string) (distribution.Descriptor, error) {
+	reader, err := p.reader.Open(name)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	mediaType := ocispec.MediaTypeImageLayer
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		mediaType = ocispec.MediaTypeImageLayerGzip
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), buffered)
+	if err != nil {
+		return distribution.Descriptor{}, err
+	}
+	return distribution.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}, nil
+}
+
+func (p *pusher) pushManifest(desc distribution.Descriptor, tag string) error {
+	payload, err := readLayoutFile(p.reader, blobPath(desc.Digest))
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`read manifest "%s"`, desc.Digest), err)
+		return err
+	}
+	if dgst := digest.FromBytes(payload); dgst != desc.Digest {
+		p.opts.WriteDebug(fmt.Sprintf(`expect manifest digest "%s", but got "%s"`, desc.Digest, dgst), errors.ErrDigestMismatch)
+		return errors.ErrDigestMismatch
+	}
+
+	mediaType := desc.MediaType
+	versioned := manifest.Versioned{}
+	if err := json.Unmarshal(payload, &versioned); err == nil && versioned.MediaType != "" {
+		mediaType = versioned.MediaType
+	}
+	man, _, err := distribution.UnmarshalManifest(mediaType, payload)
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`unmarshal manifest "%s"`, desc.Digest), err)
+		return err
+	}
+
+	if tag == "" {
+		exist, err := manifestExists(p.opts, p.manifestService, desc.Digest)
+		if err != nil {
+			p.opts.WriteDebug(fmt.Sprintf(`check manifest "%s"`, desc.Digest), err)
+			return err
+		}
+		if exist {
+			p.opts.WriteDebug(fmt.Sprintf(`manifest "%s" exists, skip`, desc.Digest), nil)
+			return nil
+		}
+	}
+
+	if list, ok := man.(*manifestlist.DeserializedManifestList); ok {
+		for _, ref := range list.Manifests {
+			if err := p.pushManifest(ref.Descriptor, ""); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, ref := range man.References() {
+			if err := p.pushBlob(ref, blobPath(ref.Digest)); err != nil {
+				return err
+			}
+		}
+	}
+	return p.putManifest(man, desc.Digest, tag)
+}
+
+func (p *pusher) putManifest(man distribution.Manifest, dgst digest.Digest, tag string) error {
+	if p.plan != nil {
+		c := change{
+			Action:     changePutManifest,
+			Repository: p.opts.Repositiory,
+			Digest:     dgst.String(),
+		}
+		if tag != "" {
+			c.Tags = []string{tag}
+		}
+		p.plan.Add(c)
+		return nil
+	}
+
+	var putOpts []distribution.ManifestServiceOption
+	if tag != "" {
+		putOpts = append(putOpts, distribution.WithTag(tag))
+	}
+	if _, err := p.manifestService.Put(p.opts.Ctx, man, putOpts...); err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`put manifest "%s"`, dgst), err)
+		return err
+	}
+	return nil
+}
+
+func (p *pusher) pushBlob(desc distribution.Descriptor, name string) error {
+	if p.pushed[desc.Digest] {
+		return nil
+	}
+	p.pushed[desc.Digest] = true
+
+	blobs := p.repo.Blobs(p.opts.Ctx)
+	if _, err := blobs.Stat(p.opts.Ctx, desc.Digest); err == nil {
+		p.opts.WriteDebug(fmt.Sprintf(`blob "%s" exists, skip`, desc.Digest), nil)
+		return nil
+	} else if err != distribution.ErrBlobUnknown {
+		p.opts.WriteDebug(fmt.Sprintf(`stat blob "%s"`, desc.Digest), err)
+		return err
+	}
+
+	reader, err := p.reader.Open(name)
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`open blob "%s"`, name), err)
+		return err
+	}
+	defer reader.Close()
+
+	if p.plan != nil {
+		verified := newVerifiedReader(reader, desc)
+		if _, err := io.Copy(io.Discard, verified); err != nil {
+			return err
+		}
+		if err := verified.Verify(); err != nil {
+			return err
+		}
+		p.plan.Add(change{
+			Action:     changeUploadBlob,
+			Repository: p.opts.Repositiory,
+			Digest:     desc.Digest.String(),
+		})
+		return nil
+	}
+
+	if p.opts.ChunkSize > 0 {
+		return uploadBlobChunked(p.opts, p.cli, p.repo, reader, desc, p.opts.ChunkSize)
+	}
+
+	writer, err := blobs.Create(p.opts.Ctx)
+	if err != nil {
+		p.opts.WriteDebug(fmt.Sprintf(`create upload for blob "%s"`, desc.Digest), err)
+		return err
+	}
+	defer writer.Close()
+	return uploadBlob(p.opts, writer, reader, desc)
+}
+
+func matchTag(name, tag string) bool {
+	if name == "" || tag == "" {
+		return false
+	}
+	return name == tag || strings.HasSuffix(name, ":"+tag)
+}
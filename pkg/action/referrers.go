@@ -0,0 +1,304 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"strings"
+
+	registryclient "github.com/distribution/distribution/registry/client"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	referrersSourceAPI       = "referrers-api"
+	referrersSourceTagSchema = "tag-schema"
+
+	cosignSignatureSuffix   = ".sig"
+	cosignAttestationSuffix = ".att"
+	cosignSBOMSuffix        = ".sbom"
+)
+
+var cosignTagSuffixes = []string{
+	cosignSignatureSuffix,
+	cosignAttestationSuffix,
+	cosignSBOMSuffix,
+}
+
+type referrer struct {
+	ArtifactType string            `json:"artifactType"`
+	MediaType    string            `json:"mediaType"`
+	Digest       digest.Digest     `json:"digest"`
+	Size         int64             `json:"size"`
+	Tag          string            `json:"tag,omitempty"`
+	Source       string            `json:"source"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+func (r referrer) Header() []string {
+	return []string{"ARTIFACT TYPE", "DIGEST", "SIZE", "TAG"}
+}
+
+func (r *referrer) Column() []string {
+	tag := r.Tag
+	if tag == "" {
+		tag = "-"
+	}
+	return []string{r.ArtifactType, r.Digest.String(), output.SizeToShow(&r.Size), tag}
+}
+
+type referrerList struct {
+	Subject   digest.Digest `json:"subject"`
+	Source    string        `json:"source"`
+	Referrers []referrer    `json:"referrers"`
+}
+
+func (l *referrerList) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, l)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, referrer{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, r := range l.Referrers {
+			if err := w.Write(r.Column()...); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+type artifactManifest struct {
+	MediaType    string               `json:"mediaType"`
+	ArtifactType string               `json:"artifactType,omitempty"`
+	Config       *ocispec.Descriptor  `json:"config,omitempty"`
+	Layers       []ocispec.Descriptor `json:"layers,omitempty"`
+	Blobs        []ocispec.Descriptor `json:"blobs,omitempty"`
+	Annotations  map[string]string    `json:"annotations,omitempty"`
+}
+
+func (m *artifactManifest) artifactType() string {
+	if m.ArtifactType != "" {
+		return m.ArtifactType
+	}
+	if m.Config != nil && m.Config.MediaType != ocispec.MediaTypeImageConfig && m.Config.MediaType != "application/vnd.oci.empty.v1+json" {
+		return m.Config.MediaType
+	}
+	for _, layers := range [][]ocispec.Descriptor{m.Layers, m.Blobs} {
+		if len(layers) > 0 {
+			return layers[0].MediaType
+		}
+	}
+	if m.Config != nil {
+		return m.Config.MediaType
+	}
+	return m.MediaType
+}
+
+func Referrers(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	if _, err := fetchManifest(opts, manifestService); err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+
+	list, err := fetchReferrers(opts, cli, repo, manifestService, opts.Digest)
+	if err != nil {
+		return err
+	}
+	if opts.ArtifactType != "" {
+		referrers := []referrer{}
+		for _, r := range list.Referrers {
+			if r.ArtifactType == opts.ArtifactType {
+				referrers = append(referrers, r)
+			}
+		}
+		list.Referrers = referrers
+	}
+	return list.Output(opts)
+}
+
+func fetchReferrers(opts *option.Options, cli *client.Client, repo distribution.Repository, manifestService distribution.ManifestService, subject digest.Digest) (*referrerList, error) {
+	list := &referrerList{
+		Subject:   subject,
+		Source:    referrersSourceAPI,
+		Referrers: []referrer{},
+	}
+	index, err := fetchReferrersIndex(opts, cli, repo, subject)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		for _, desc := range index.Manifests {
+			list.add(referrer{
+				ArtifactType: desc.ArtifactType,
+				MediaType:    desc.MediaType,
+				Digest:       desc.Digest,
+				Size:         desc.Size,
+				Source:       referrersSourceAPI,
+				Annotations:  desc.Annotations,
+			})
+		}
+	} else {
+		opts.WriteDebug("referrers api is not supported, fall back to tag schema", nil)
+		list.Source = referrersSourceTagSchema
+		tag := referrersTag(subject)
+		man, dgst, err := fetchManifestByTag(opts, manifestService, tag)
+		if err != nil {
+			return nil, err
+		}
+		if man != nil {
+			_, payload, err := man.Payload()
+			if err != nil {
+				return nil, err
+			}
+			fallback := ocispec.Index{}
+			if err := json.Unmarshal(payload, &fallback); err != nil {
+				opts.WriteDebug(fmt.Sprintf(`unmarshal referrers index "%s"`, dgst), err)
+				return nil, err
+			}
+			for _, desc := range fallback.Manifests {
+				list.add(referrer{
+					ArtifactType: desc.ArtifactType,
+					MediaType:    desc.MediaType,
+					Digest:       desc.Digest,
+					Size:         desc.Size,
+					Tag:          tag,
+					Source:       referrersSourceTagSchema,
+					Annotations:  desc.Annotations,
+				})
+			}
+		}
+	}
+
+	for _, suffix := range cosignTagSuffixes {
+		tag := referrersTag(subject) + suffix
+		man, dgst, err := fetchManifestByTag(opts, manifestService, tag)
+		if err != nil {
+			return nil, err
+		}
+		if man == nil {
+			continue
+		}
+		mediaType, payload, err := man.Payload()
+		if err != nil {
+			return nil, err
+		}
+		artifact := artifactManifest{}
+		if err := json.Unmarshal(payload, &artifact); err != nil {
+			opts.WriteDebug(fmt.Sprintf(`unmarshal manifest "%s"`, dgst), err)
+			return nil, err
+		}
+		list.add(referrer{
+			ArtifactType: artifact.artifactType(),
+			MediaType:    mediaType,
+			Digest:       dgst,
+			Size:         int64(len(payload)),
+			Tag:          tag,
+			Source:       referrersSourceTagSchema,
+			Annotations:  artifact.Annotations,
+		})
+	}
+	return list, nil
+}
+
+func (l *referrerList) add(r referrer) {
+	for i := range l.Referrers {
+		if l.Referrers[i].Digest == r.Digest {
+			if l.Referrers[i].Tag == "" {
+				l.Referrers[i].Tag = r.Tag
+			}
+			return
+		}
+	}
+	l.Referrers = append(l.Referrers, r)
+}
+
+func referrersTag(dgst digest.Digest) string {
+	return dgst.Algorithm().String() + "-" + dgst.Encoded()
+}
+
+func fetchManifestByTag(opts *option.Options, manifestService distribution.ManifestService, tag string) (distribution.Manifest, digest.Digest, error) {
+	var dgst digest.Digest
+	man, err := manifestService.Get(opts.Ctx, "", distribution.WithTag(tag), registryclient.ReturnContentDigest(&dgst))
+	if isManifestUnknown(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`get manifest for "%s"`, tag), err)
+		return nil, "", err
+	}
+	return man, dgst, nil
+}
+
+func fetchReferrersIndex(opts *option.Options, cli *client.Client, repo distribution.Repository, subject digest.Digest) (*ocispec.Index, error) {
+	u, err := url.Parse(cli.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + fmt.Sprintf("/v2/%s/referrers/%s", repo.Named().Name(), subject)
+	if opts.ArtifactType != "" {
+		u.RawQuery = url.Values{"artifactType": []string{opts.ArtifactType}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(opts.Ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ocispec.MediaTypeImageIndex)
+
+	roundTripper, err := cli.GetRoundTripper(repo.Named().Name(), client.PullAction)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		opts.WriteDebug("request referrers api", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if !registryclient.SuccessStatus(resp.StatusCode) {
+		return nil, registryclient.HandleErrorResponse(resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	index := &ocispec.Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		opts.WriteDebug("unmarshal referrers index", err)
+		return nil, err
+	}
+	return index, nil
+}
@@ -0,0 +1,23 @@
+package action
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferrerListAdd(t *testing.T) {
+	list := &referrerList{Referrers: []referrer{}}
+	list.add(referrer{Digest: "sha256:sig", Source: referrersSourceAPI})
+	list.add(referrer{Digest: "sha256:sbom", Source: referrersSourceAPI})
+	list.add(referrer{Digest: "sha256:sig", Tag: "sha256-abc.sig", Source: referrersSourceTagSchema})
+	list.add(referrer{Digest: "sha256:att", Tag: "sha256-abc.att", Source: referrersSourceTagSchema})
+
+	expect := []referrer{
+		{Digest: "sha256:sig", Tag: "sha256-abc.sig", Source: referrersSourceAPI},
+		{Digest: "sha256:sbom", Source: referrersSourceAPI},
+		{Digest: "sha256:att", Tag: "sha256-abc.att", Source: referrersSourceTagSchema},
+	}
+	if !reflect.DeepEqual(list.Referrers, expect) {
+		t.Errorf("expect %+v, but got %+v", expect, list.Referrers)
+	}
+}
@@ -0,0 +1,546 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	sbomFormatSPDX      = "spdx"
+	sbomFormatCycloneDX = "cyclonedx"
+
+	sbomSourceAttestationManifest = "attestation-manifest"
+
+	inTotoPayloadType         = "application/vnd.in-toto+json"
+	spdxPredicatePrefix       = "https://spdx.dev/Document"
+	cycloneDXPredicatePrefix  = "https://cyclonedx.org/bom"
+	referenceTypeAnnotation   = "vnd.docker.reference.type"
+	referenceDigestAnnotation = "vnd.docker.reference.digest"
+	attestationManifestType   = "attestation-manifest"
+
+	maxSBOMSize = 64 << 20
+)
+
+type sbomPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	License string `json:"license,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type sbomDocument struct {
+	Format   string        `json:"format"`
+	Source   string        `json:"source"`
+	Manifest digest.Digest `json:"manifest"`
+	Digest   digest.Digest `json:"digest"`
+	Tag      string        `json:"tag,omitempty"`
+	Packages []sbomPackage `json:"packages"`
+	raw      []byte
+}
+
+type sbomList struct {
+	Subject   digest.Digest  `json:"subject"`
+	Documents []sbomDocument `json:"documents"`
+}
+
+func (l *sbomList) Output(opts *option.Options) error {
+	if opts.Raw {
+		for _, doc := range l.Documents {
+			if _, err := opts.StdOut.Write(doc.raw); err != nil {
+				return err
+			}
+			if !bytes.HasSuffix(doc.raw, []byte("\n")) {
+				if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, l)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, "SBOM", "FORMAT", "SOURCE", "MANIFEST", "PACKAGES")
+		if err != nil {
+			return err
+		}
+		for i, doc := range l.Documents {
+			if err := w.Write(strconv.Itoa(i+1), doc.Format, doc.Source, doc.Manifest.String(), strconv.Itoa(len(doc.Packages))); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+			return err
+		}
+		if w, err = output.NewTextWriter(opts.StdOut, "SBOM", "NAME", "VERSION", "LICENSE", "PURL"); err != nil {
+			return err
+		}
+		for i, doc := range l.Documents {
+			for _, p := range doc.Packages {
+				if err := w.Write(strconv.Itoa(i+1), p.Name, orDash(p.Version), orDash(p.License), orDash(p.PURL)); err != nil {
+					return err
+				}
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func SBOM(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+
+	subjects := []digest.Digest{opts.Digest}
+	list, _ := man.(*manifestlist.DeserializedManifestList)
+	image := digest.Digest("")
+	if list != nil && opts.Platform != "" {
+		if _, image, err = resolveImageManifest(opts, manifestService, man, opts.Digest); err != nil {
+			return err
+		}
+		subjects = append(subjects, image)
+	}
+
+	sboms, err := collectSBOMs(opts, cli, repo, manifestService, list, subjects, image)
+	if err != nil {
+		return err
+	}
+	if len(sboms.Documents) == 0 {
+		opts.WriteDebug(fmt.Sprintf(`find sbom for "%s"`, opts.Digest), errors.ErrSBOMNotFound)
+		return errors.ErrSBOMNotFound
+	}
+	return sboms.Output(opts)
+}
+
+func collectSBOMs(
+	opts *option.Options,
+	cli *client.Client,
+	repo distribution.Repository,
+	manifestService distribution.ManifestService,
+	list *manifestlist.DeserializedManifestList,
+	subjects []digest.Digest,
+	image digest.Digest) (*sbomList, error) {
+
+	c := &sbomCollector{
+		opts:            opts,
+		repo:            repo,
+		manifestService: manifestService,
+		seen:            map[digest.Digest]bool{},
+		list: &sbomList{
+			Subject:   subjects[0],
+			Documents: []sbomDocument{},
+		},
+	}
+	for _, subject := range subjects {
+		referrers, err := fetchReferrers(opts, cli, repo, manifestService, subject)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range referrers.Referrers {
+			if r.ArtifactType == cosignSimpleSigningMediaType {
+				continue
+			}
+			if err := c.collectManifest(r.Digest, r.Source, r.Tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if list != nil {
+		for _, ref := range list.Manifests {
+			if ref.Annotations[referenceTypeAnnotation] != attestationManifestType {
+				continue
+			}
+			if image != "" && ref.Annotations[referenceDigestAnnotation] != image.String() {
+				continue
+			}
+			if err := c.collectManifest(ref.Digest, sbomSourceAttestationManifest, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.list, nil
+}
+
+type sbomCollector struct {
+	opts            *option.Options
+	repo            distribution.Repository
+	manifestService distribution.ManifestService
+	seen            map[digest.Digest]bool
+	list            *sbomList
+}
+
+func (c *sbomCollector) collectManifest(dgst digest.Digest, source, tag string) error {
+	man, err := c.manifestService.Get(c.opts.Ctx, dgst)
+	if err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, dgst), err)
+		if isManifestUnknown(err) {
+			return nil
+		}
+		return err
+	}
+	_, payload, err := man.Payload()
+	if err != nil {
+		return err
+	}
+	artifact := artifactManifest{}
+	if err := json.Unmarshal(payload, &artifact); err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`unmarshal manifest "%s"`, dgst), err)
+		return nil
+	}
+
+	for _, layer := range append(artifact.Layers, artifact.Blobs...) {
+		if c.seen[layer.Digest] || !maybeSBOMMediaType(layer.MediaType) {
+			continue
+		}
+		c.seen[layer.Digest] = true
+		if layer.Size > maxSBOMSize {
+			c.opts.WriteDebug(fmt.Sprintf(`skip blob "%s" of %d bytes`, layer.Digest, layer.Size), nil)
+			continue
+		}
+		data, err := c.repo.Blobs(c.opts.Ctx).Get(c.opts.Ctx, layer.Digest)
+		if err != nil {
+			c.opts.WriteDebug(fmt.Sprintf(`get blob "%s"`, layer.Digest), err)
+			return err
+		}
+		format, raw := extractSBOM(data)
+		if format == "" {
+			c.opts.WriteDebug(fmt.Sprintf(`blob "%s" of "%s" is not a sbom`, layer.Digest, layer.MediaType), nil)
+			continue
+		}
+		packages, err := parseSBOMPackages(format, raw)
+		if err != nil {
+			c.opts.WriteDebug(fmt.Sprintf(`parse sbom "%s"`, layer.Digest), err)
+			packages = []sbomPackage{}
+		}
+		c.list.Documents = append(c.list.Documents, sbomDocument{
+			Format:   format,
+			Source:   source,
+			Manifest: dgst,
+			Digest:   layer.Digest,
+			Tag:      tag,
+			Packages: packages,
+			raw:      raw,
+		})
+	}
+	return nil
+}
+
+func maybeSBOMMediaType(mediaType string) bool {
+	return strings.Contains(mediaType, "json") || strings.Contains(mediaType, "xml") ||
+		strings.Contains(mediaType, "spdx") || strings.HasPrefix(mediaType, "text/")
+}
+
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+func extractSBOM(data []byte) (string, []byte) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		probe := struct {
+			SPDXVersion string `json:"spdxVersion"`
+			BOMFormat   string `json:"bomFormat"`
+			dsseEnvelope
+			inTotoStatement
+		}{}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return "", nil
+		}
+		switch {
+		case probe.SPDXVersion != "":
+			return sbomFormatSPDX, data
+		case probe.BOMFormat == "CycloneDX":
+			return sbomFormatCycloneDX, data
+		case probe.PayloadType == inTotoPayloadType:
+			payload, err := base64.StdEncoding.DecodeString(probe.Payload)
+			if err != nil {
+				return "", nil
+			}
+			return extractSBOM(payload)
+		case probe.PredicateType != "":
+			if !strings.HasPrefix(probe.PredicateType, spdxPredicatePrefix) && !strings.HasPrefix(probe.PredicateType, cycloneDXPredicatePrefix) {
+				return "", nil
+			}
+			return extractPredicate(probe.Predicate)
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		if bytes.Contains(trimmed, []byte("cyclonedx.org/schema/bom")) {
+			return sbomFormatCycloneDX, data
+		}
+	case bytes.HasPrefix(trimmed, []byte("SPDXVersion:")) || bytes.Contains(trimmed, []byte("\nSPDXVersion:")):
+		return sbomFormatSPDX, data
+	}
+	return "", nil
+}
+
+func extractPredicate(predicate json.RawMessage) (string, []byte) {
+	var text string
+	if err := json.Unmarshal(predicate, &text); err == nil {
+		return extractSBOM([]byte(text))
+	}
+	wrapped := struct {
+		Data json.RawMessage `json:"Data"`
+	}{}
+	if err := json.Unmarshal(predicate, &wrapped); err == nil && len(wrapped.Data) > 0 {
+		return extractPredicate(wrapped.Data)
+	}
+	return extractSBOM(predicate)
+}
+
+func parseSBOMPackages(format string, data []byte) ([]sbomPackage, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case format == sbomFormatSPDX && bytes.HasPrefix(trimmed, []byte("{")):
+		return parseSPDXJSON(trimmed)
+	case format == sbomFormatSPDX:
+		return parseSPDXTagValue(trimmed)
+	case format == sbomFormatCycloneDX && bytes.HasPrefix(trimmed, []byte("<")):
+		return parseCycloneDXXML(trimmed)
+	case format == sbomFormatCycloneDX:
+		return parseCycloneDXJSON(trimmed)
+	}
+	return nil, errors.ErrUnknownSBOM
+}
+
+func parseSPDXJSON(data []byte) ([]sbomPackage, error) {
+	doc := struct {
+		Packages []struct {
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+			ExternalRefs     []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	packages := make([]sbomPackage, 0, len(doc.Packages))
+	for _, p := range doc.Packages {
+		pkg := sbomPackage{
+			Name:    p.Name,
+			Version: p.VersionInfo,
+			License: spdxLicense(p.LicenseConcluded, p.LicenseDeclared),
+		}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				pkg.PURL = ref.ReferenceLocator
+				break
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+func parseSPDXTagValue(data []byte) ([]sbomPackage, error) {
+	packages := []sbomPackage{}
+	var pkg *sbomPackage
+	var concluded, declared string
+	finish := func() {
+		if pkg != nil {
+			pkg.License = spdxLicense(concluded, declared)
+			packages = append(packages, *pkg)
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxSBOMSize)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "PackageName":
+			finish()
+			pkg, concluded, declared = &sbomPackage{Name: value}, "", ""
+		case "FileName", "SnippetSPDXID":
+			finish()
+			pkg = nil
+		}
+		if pkg == nil {
+			continue
+		}
+		switch key {
+		case "PackageVersion":
+			pkg.Version = value
+		case "PackageLicenseConcluded":
+			concluded = value
+		case "PackageLicenseDeclared":
+			declared = value
+		case "ExternalRef":
+			if fields := strings.Fields(value); len(fields) == 3 && fields[1] == "purl" && pkg.PURL == "" {
+				pkg.PURL = fields[2]
+			}
+		}
+	}
+	finish()
+	return packages, scanner.Err()
+}
+
+func spdxLicense(concluded, declared string) string {
+	for _, l := range []string{concluded, declared} {
+		if l != "" && l != "NOASSERTION" && l != "NONE" {
+			return l
+		}
+	}
+	return ""
+}
+
+type cycloneDXLicense struct {
+	License struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"license"`
+	Expression string `json:"expression"`
+}
+
+type cycloneDXComponent struct {
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Licenses   []cycloneDXLicense   `json:"licenses"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXXMLComponent struct {
+	Name     string `xml:"name"`
+	Version  string `xml:"version"`
+	PURL     string `xml:"purl"`
+	Licenses []struct {
+		ID   string `xml:"id"`
+		Name string `xml:"name"`
+	} `xml:"licenses>license"`
+	Expressions []string                `xml:"licenses>expression"`
+	Components  []cycloneDXXMLComponent `xml:"components>component"`
+}
+
+func (c *cycloneDXXMLComponent) component() cycloneDXComponent {
+	component := cycloneDXComponent{
+		Name:    c.Name,
+		Version: c.Version,
+		PURL:    c.PURL,
+	}
+	for _, l := range c.Licenses {
+		license := cycloneDXLicense{}
+		license.License.ID, license.License.Name = l.ID, l.Name
+		component.Licenses = append(component.Licenses, license)
+	}
+	for _, e := range c.Expressions {
+		component.Licenses = append(component.Licenses, cycloneDXLicense{Expression: e})
+	}
+	for _, child := range c.Components {
+		component.Components = append(component.Components, child.component())
+	}
+	return component
+}
+
+func parseCycloneDXJSON(data []byte) ([]sbomPackage, error) {
+	bom := struct {
+		Components []cycloneDXComponent `json:"components"`
+	}{}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return nil, err
+	}
+	return cycloneDXPackages(nil, bom.Components), nil
+}
+
+func parseCycloneDXXML(data []byte) ([]sbomPackage, error) {
+	bom := struct {
+		Components []cycloneDXXMLComponent `xml:"components>component"`
+	}{}
+	if err := xml.Unmarshal(data, &bom); err != nil {
+		return nil, err
+	}
+	components := make([]cycloneDXComponent, 0, len(bom.Components))
+	for _, c := range bom.Components {
+		components = append(components, c.component())
+	}
+	return cycloneDXPackages(nil, components), nil
+}
+
+func cycloneDXPackages(packages []sbomPackage, components []cycloneDXComponent) []sbomPackage {
+	if packages == nil {
+		packages = []sbomPackage{}
+	}
+	for _, c := range components {
+		licenses := []string{}
+		for _, l := range c.Licenses {
+			switch {
+			case l.Expression != "":
+				licenses = append(licenses, l.Expression)
+			case l.License.ID != "":
+				licenses = append(licenses, l.License.ID)
+			case l.License.Name != "":
+				licenses = append(licenses, l.License.Name)
+			}
+		}
+		packages = append(packages, sbomPackage{
+			Name:    c.Name,
+			Version: c.Version,
+			License: strings.Join(licenses, " OR "),
+			PURL:    c.PURL,
+		})
+		packages = cycloneDXPackages(packages, c.Components)
+	}
+	return packages
+}
@@ -0,0 +1,77 @@
+package action
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtractSBOM(t *testing.T) {
+	spdx := `{"spdxVersion":"SPDX-2.3","packages":[{"name":"musl","versionInfo":"1.2.3","licenseConcluded":"NOASSERTION","licenseDeclared":"MIT","externalRefs":[{"referenceCategory":"PACKAGE-MANAGER","referenceType":"purl","referenceLocator":"pkg:apk/alpine/musl@1.2.3"}]}]}`
+	tagValue := "SPDXVersion: SPDX-2.3\nDataLicense: CC0-1.0\n\nPackageName: zlib\nPackageVersion: 1.2.13\nPackageLicenseConcluded: Zlib\nExternalRef: PACKAGE-MANAGER purl pkg:apk/alpine/zlib@1.2.13\n\nFileName: ./lib/libz.so\nLicenseConcluded: Zlib\n"
+	cyclonedx := `{"bomFormat":"CycloneDX","components":[{"name":"openssl","version":"3.0.8","purl":"pkg:apk/alpine/openssl@3.0.8","licenses":[{"license":{"id":"Apache-2.0"}}],"components":[{"name":"libcrypto3","licenses":[{"expression":"Apache-2.0 AND MIT"}]}]}]}`
+	statement := func(predicateType string, predicate interface{}) string {
+		data, _ := json.Marshal(map[string]interface{}{"_type": "https://in-toto.io/Statement/v0.1", "predicateType": predicateType, "predicate": predicate})
+		return string(data)
+	}
+	envelope := func(payload string) string {
+		data, _ := json.Marshal(map[string]string{"payloadType": inTotoPayloadType, "payload": base64.StdEncoding.EncodeToString([]byte(payload))})
+		return string(data)
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		format   string
+		packages []sbomPackage
+	}{
+		{
+			name:     "spdx json",
+			data:     spdx,
+			format:   sbomFormatSPDX,
+			packages: []sbomPackage{{Name: "musl", Version: "1.2.3", License: "MIT", PURL: "pkg:apk/alpine/musl@1.2.3"}},
+		},
+		{
+			name:     "spdx tag value in dsse envelope",
+			data:     envelope(statement("https://spdx.dev/Document", map[string]string{"Data": tagValue})),
+			format:   sbomFormatSPDX,
+			packages: []sbomPackage{{Name: "zlib", Version: "1.2.13", License: "Zlib", PURL: "pkg:apk/alpine/zlib@1.2.13"}},
+		},
+		{
+			name:   "cyclonedx in-toto statement",
+			data:   statement("https://cyclonedx.org/bom/v1.4", json.RawMessage(cyclonedx)),
+			format: sbomFormatCycloneDX,
+			packages: []sbomPackage{
+				{Name: "openssl", Version: "3.0.8", License: "Apache-2.0", PURL: "pkg:apk/alpine/openssl@3.0.8"},
+				{Name: "libcrypto3", License: "Apache-2.0 AND MIT"},
+			},
+		},
+		{
+			name: "provenance",
+			data: envelope(statement("https://slsa.dev/provenance/v0.2", map[string]string{})),
+		},
+		{
+			name: "cosign signature",
+			data: `{"critical":{"type":"cosign container image signature"}}`,
+		},
+	}
+	for _, tt := range tests {
+		format, raw := extractSBOM([]byte(tt.data))
+		if format != tt.format {
+			t.Errorf("%s: expect format %q, but got %q", tt.name, tt.format, format)
+			continue
+		}
+		if format == "" {
+			continue
+		}
+		packages, err := parseSBOMPackages(format, raw)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(packages, tt.packages) {
+			t.Errorf("%s: expect packages %+v, but got %+v", tt.name, tt.packages, packages)
+		}
+	}
+}
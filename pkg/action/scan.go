@@ -0,0 +1,380 @@
+package action
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/schema2"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	scanSourceSBOM   = "sbom"
+	scanSourceLayers = "layers"
+)
+
+type imageScan struct {
+	Tag             string          `json:"tag"`
+	Platform        string          `json:"platform,omitempty"`
+	Digest          digest.Digest   `json:"digest"`
+	OS              string          `json:"os,omitempty"`
+	Source          string          `json:"source"`
+	Packages        int             `json:"packages"`
+	Unsupported     []string        `json:"unsupported,omitempty"`
+	Vulnerabilities []vulnerability `json:"vulnerabilities"`
+}
+
+type scanResult struct {
+	Repository string      `json:"repository"`
+	Advisories int         `json:"advisories"`
+	Images     []imageScan `json:"images"`
+}
+
+func (r *scanResult) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, r)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, "TAG", "PLATFORM", "OS", "SOURCE", "PACKAGES", "VULNERABILITIES", "UNSUPPORTED")
+		if err != nil {
+			return err
+		}
+		for _, image := range r.Images {
+			if err := w.Write(image.Tag, orDash(image.Platform), orDash(image.OS), image.Source, strconv.Itoa(image.Packages),
+				strconv.Itoa(len(image.Vulnerabilities)), orDash(strings.Join(image.Unsupported, ","))); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(opts.StdOut); err != nil {
+			return err
+		}
+		if w, err = output.NewTextWriter(opts.StdOut, "TAG", "PLATFORM", "PACKAGE", "VERSION", "CVE", "FIXED"); err != nil {
+			return err
+		}
+		for _, image := range r.Images {
+			for _, v := range image.Vulnerabilities {
+				if err := w.Write(image.Tag, orDash(image.Platform), v.Package, v.Version, v.CVE, orDash(v.Fixed)); err != nil {
+					return err
+				}
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+type scanTarget struct {
+	tag        string
+	platform   string
+	digest     digest.Digest
+	listDigest digest.Digest
+}
+
+func Scan(opts *option.Options) error {
+	db, err := loadAdvisoryDB(opts.AdvisoryDB)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`load advisory database "%s"`, opts.AdvisoryDB), err)
+		return err
+	}
+	opts.WriteDebug(fmt.Sprintf("loaded %d advisories", db.count), nil)
+
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	targets, err := scanTargets(opts, cli, manifestService)
+	if err != nil {
+		return err
+	}
+
+	s := &scanner{
+		opts:            opts,
+		cli:             cli,
+		repo:            repo,
+		manifestService: manifestService,
+		db:              db,
+		scanned:         map[digest.Digest]*imageScan{},
+	}
+	result := &scanResult{
+		Repository: opts.Repositiory,
+		Advisories: db.count,
+		Images:     []imageScan{},
+	}
+	unsupported := false
+	for _, target := range targets {
+		image, err := s.scan(target)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			result.Images = append(result.Images, *image)
+			unsupported = unsupported || len(image.Unsupported) > 0
+		}
+	}
+	if err := result.Output(opts); err != nil {
+		return err
+	}
+	if unsupported {
+		return errors.ErrPackageDBUnsupported
+	}
+	return nil
+}
+
+func scanTargets(opts *option.Options, cli *client.Client, manifestService distribution.ManifestService) ([]scanTarget, error) {
+	if opts.Tag != "" || opts.Digest != "" {
+		man, err := fetchManifest(opts, manifestService)
+		if err != nil {
+			opts.WriteDebug("fetch manifest", err)
+			return nil, err
+		}
+		target := scanTarget{tag: opts.Tag, digest: opts.Digest}
+		if target.tag == "" {
+			target.tag = opts.Digest.String()
+		}
+		if _, ok := man.(*manifestlist.DeserializedManifestList); ok {
+			target.listDigest = opts.Digest
+			if _, target.digest, err = resolveImageManifest(opts, manifestService, man, opts.Digest); err != nil {
+				return nil, err
+			}
+			platform, _ := opts.SelectedPlatform()
+			target.platform = platform.String()
+		}
+		return []scanTarget{target}, nil
+	}
+
+	_, tags, err := getAllTags(opts, cli)
+	if err != nil {
+		opts.WriteDebug("get all tags", err)
+		return nil, err
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Tag < tags[j].Tag
+	})
+	var targets []scanTarget
+	for _, t := range tags {
+		if artifactTagPattern.MatchString(t.Tag) {
+			continue
+		}
+		targets = append(targets, scanTarget{
+			tag:        t.Tag,
+			platform:   t.Platform,
+			digest:     digest.Digest(t.Digest),
+			listDigest: digest.Digest(t.ListDigest),
+		})
+	}
+	return targets, nil
+}
+
+type scanner struct {
+	opts            *option.Options
+	cli             *client.Client
+	repo            distribution.Repository
+	manifestService distribution.ManifestService
+	db              *advisoryDB
+	scanned         map[digest.Digest]*imageScan
+}
+
+func (s *scanner) scan(target scanTarget) (*imageScan, error) {
+	if scanned, ok := s.scanned[target.digest]; ok {
+		if scanned == nil {
+			return nil, nil
+		}
+		image := *scanned
+		image.Tag, image.Platform = target.tag, target.platform
+		return &image, nil
+	}
+
+	man, err := s.manifestService.Get(s.opts.Ctx, target.digest)
+	if err != nil {
+		s.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, target.digest), err)
+		return nil, err
+	}
+	if config, ok := imageConfig(man); ok && config.MediaType != schema2.MediaTypeImageConfig && config.MediaType != ocispec.MediaTypeImageConfig {
+		s.opts.WriteDebug(fmt.Sprintf(`skip "%s" with config of "%s"`, target.digest, config.MediaType), nil)
+		s.scanned[target.digest] = nil
+		return nil, nil
+	}
+
+	image := &imageScan{
+		Tag:             target.tag,
+		Platform:        target.platform,
+		Digest:          target.digest,
+		Vulnerabilities: []vulnerability{},
+	}
+	packages, err := s.sbomPackages(target)
+	if err != nil {
+		return nil, err
+	}
+	image.Source = scanSourceSBOM
+	if len(packages) == 0 {
+		image.Source = scanSourceLayers
+		if packages, err = s.layerPackages(man, image); err != nil {
+			return nil, err
+		}
+	}
+
+	image.Packages = len(packages)
+	for _, pkg := range packages {
+		image.Vulnerabilities = append(image.Vulnerabilities, s.db.match(pkg)...)
+	}
+	sort.SliceStable(image.Vulnerabilities, func(i, j int) bool {
+		a, b := image.Vulnerabilities[i], image.Vulnerabilities[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.CVE < b.CVE
+	})
+	s.scanned[target.digest] = image
+	return image, nil
+}
+
+func (s *scanner) sbomPackages(target scanTarget) ([]installedPackage, error) {
+	subjects := []digest.Digest{target.digest}
+	var list *manifestlist.DeserializedManifestList
+	if target.listDigest != "" {
+		subjects = append([]digest.Digest{target.listDigest}, subjects...)
+		man, err := s.manifestService.Get(s.opts.Ctx, target.listDigest)
+		if err != nil {
+			s.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, target.listDigest), err)
+			return nil, err
+		}
+		list, _ = man.(*manifestlist.DeserializedManifestList)
+	}
+	sboms, err := collectSBOMs(s.opts, s.cli, s.repo, s.manifestService, list, subjects, target.digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []installedPackage
+	seen := map[string]bool{}
+	for _, doc := range sboms.Documents {
+		for _, p := range doc.Packages {
+			purl, ok := parsePackageURL(p.PURL)
+			if !ok || seen[p.PURL] {
+				continue
+			}
+			seen[p.PURL] = true
+			if pkg, ok := purl.installedPackage(); ok {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	return packages, nil
+}
+
+func (s *scanner) layerPackages(man distribution.Manifest, image *imageScan) ([]installedPackage, error) {
+	layers, err := imageLayers(man)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := fileTree{}
+	contents := map[string][]byte{}
+	blobs := s.repo.Blobs(s.opts.Ctx)
+	for _, layer := range layers {
+		var entries []layerEntry
+		if err := walkLayer(s.opts, blobs, layer.Digest, func(header *tar.Header, reader io.Reader) error {
+			entry := newLayerEntry(header)
+			entries = append(entries, entry)
+			if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA || !isPackageDBFile(entry.Name) {
+				return nil
+			}
+			if header.Size > maxPackageDBSize {
+				s.opts.WriteDebug(fmt.Sprintf(`skip "%s" of %d bytes`, entry.Name, header.Size), nil)
+				return nil
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			contents[layer.Digest.String()+"/"+entry.Name] = data
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		tree.apply(layer.Digest.String(), entries)
+	}
+	file := func(name string) ([]byte, bool) {
+		f, ok := tree[name]
+		if !ok {
+			return nil, false
+		}
+		data, ok := contents[f.Layer+"/"+name]
+		return data, ok
+	}
+
+	release := osRelease{}
+	for _, name := range []string{osReleaseFile, osReleaseFallback} {
+		if data, ok := file(name); ok {
+			release = parseOSRelease(data)
+			break
+		}
+	}
+	image.OS = release.PrettyName
+	if image.OS == "" {
+		image.OS = release.ID
+	}
+	ecosystem := release.ecosystem()
+	if ecosystem == "" {
+		s.opts.WriteDebug(fmt.Sprintf(`unknown distribution "%s %s" of "%s"`, release.ID, release.VersionID, image.Digest), nil)
+	}
+
+	var packages []installedPackage
+	add := func(name string, parse func([]byte, string) ([]installedPackage, error)) {
+		data, ok := file(name)
+		if !ok {
+			return
+		}
+		found, err := parse(data, ecosystem)
+		if err != nil {
+			s.opts.WriteDebug(fmt.Sprintf(`parse "%s" of "%s"`, name, image.Digest), err)
+			image.Unsupported = append(image.Unsupported, name)
+			return
+		}
+		packages = append(packages, found...)
+	}
+	add(dpkgStatusFile, parseDpkgStatus)
+	for _, f := range tree.sorted() {
+		if path.Dir(f.Name) == dpkgStatusDir {
+			add(f.Name, parseDpkgStatus)
+		}
+	}
+	add(apkInstalledFile, parseApkInstalled)
+	add(rpmSQLiteFile, parseRPMSQLite)
+	add(rpmSQLiteSysimage, parseRPMSQLite)
+	for _, name := range []string{rpmBerkeleyDBFile, rpmNDBSysimage} {
+		if _, ok := file(name); ok {
+			s.opts.WriteDebug(fmt.Sprintf(`rpm database "%s" of "%s" is not supported`, name, image.Digest), nil)
+			image.Unsupported = append(image.Unsupported, name)
+		}
+	}
+	return packages, nil
+}
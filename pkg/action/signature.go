@@ -0,0 +1,239 @@
+package action
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	cosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	cosignSignatureType          = "cosign container image signature"
+
+	signatureStatusValid   = "valid"
+	signatureStatusInvalid = "invalid"
+)
+
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional,omitempty"`
+}
+
+type signatureStatus struct {
+	Digest          digest.Digest `json:"digest"`
+	DockerReference string        `json:"dockerReference,omitempty"`
+	Status          string        `json:"status"`
+	Reason          string        `json:"reason,omitempty"`
+}
+
+func (s signatureStatus) Header() []string {
+	return []string{"SIGNATURE", "REFERENCE", "STATUS", "REASON"}
+}
+
+func (s *signatureStatus) Column() []string {
+	ref, reason := s.DockerReference, s.Reason
+	if ref == "" {
+		ref = "-"
+	}
+	if reason == "" {
+		reason = "-"
+	}
+	return []string{s.Digest.String(), ref, s.Status, reason}
+}
+
+type signatureResult struct {
+	Repository string            `json:"repository"`
+	Digest     digest.Digest     `json:"digest"`
+	Tag        string            `json:"signatureTag"`
+	Signatures []signatureStatus `json:"signatures"`
+}
+
+func (r *signatureResult) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, r)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, signatureStatus{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, s := range r.Signatures {
+			if err := w.Write(s.Column()...); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+func VerifySignature(opts *option.Options) error {
+	key, err := loadPublicKey(opts.Key)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`load public key "%s"`, opts.Key), err)
+		return err
+	}
+
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	if _, err := fetchManifest(opts, manifestService); err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+
+	tag := referrersTag(opts.Digest) + cosignSignatureSuffix
+	man, dgst, err := fetchManifestByTag(opts, manifestService, tag)
+	if err != nil {
+		return err
+	}
+	if man == nil {
+		opts.WriteDebug(fmt.Sprintf(`find signature tag "%s"`, tag), errors.ErrSignatureNotFound)
+		return errors.ErrSignatureNotFound
+	}
+	_, payload, err := man.Payload()
+	if err != nil {
+		return err
+	}
+	artifact := artifactManifest{}
+	if err := json.Unmarshal(payload, &artifact); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`unmarshal manifest "%s"`, dgst), err)
+		return err
+	}
+
+	result := &signatureResult{
+		Repository: opts.Repositiory,
+		Digest:     opts.Digest,
+		Tag:        tag,
+		Signatures: []signatureStatus{},
+	}
+	valid := 0
+	for _, layer := range artifact.Layers {
+		if layer.MediaType != cosignSimpleSigningMediaType {
+			continue
+		}
+		status := signatureStatus{Digest: layer.Digest, Status: signatureStatusInvalid}
+		data, err := repo.Blobs(opts.Ctx).Get(opts.Ctx, layer.Digest)
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`get signature payload "%s"`, layer.Digest), err)
+			status.Reason = err.Error()
+		} else if layer.Digest.Validate() != nil || layer.Digest.Algorithm().FromBytes(data) != layer.Digest {
+			status.Reason = errors.ErrDigestMismatch.Error()
+		} else if ref, err := verifySimpleSigning(key, data, layer.Annotations[cosignSignatureAnnotation], opts.Digest); err != nil {
+			opts.WriteDebug(fmt.Sprintf(`verify signature "%s"`, layer.Digest), err)
+			status.Reason = err.Error()
+		} else {
+			status.DockerReference = ref
+			status.Status = signatureStatusValid
+			valid++
+		}
+		result.Signatures = append(result.Signatures, status)
+	}
+
+	if err := result.Output(opts); err != nil {
+		opts.WriteDebug("output signature result", err)
+		return err
+	}
+	if valid == 0 {
+		return errors.ErrNoValidSignature
+	}
+	return nil
+}
+
+func loadPublicKey(file string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.ErrInvalidPublicKey
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidPublicKey, err)
+	}
+	return key, nil
+}
+
+func verifySimpleSigning(key crypto.PublicKey, payload []byte, signature string, subject digest.Digest) (string, error) {
+	if signature == "" {
+		return "", errors.ErrSignatureMissing
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", fmt.Errorf("decode signature: %v", err)
+	}
+	if err := verifyPayload(key, payload, sig); err != nil {
+		return "", err
+	}
+
+	ss := simpleSigning{}
+	if err := json.Unmarshal(payload, &ss); err != nil {
+		return "", fmt.Errorf("unmarshal signature payload: %v", err)
+	}
+	if ss.Critical.Type != cosignSignatureType {
+		return "", fmt.Errorf(`unknown signature type "%s"`, ss.Critical.Type)
+	}
+	if ss.Critical.Image.DockerManifestDigest != subject.String() {
+		return "", fmt.Errorf(`signature is for "%s", not "%s"`, ss.Critical.Image.DockerManifestDigest, subject)
+	}
+	return ss.Critical.Identity.DockerReference, nil
+}
+
+func verifyPayload(key crypto.PublicKey, payload, sig []byte) error {
+	hashed := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(k, hashed[:], sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig) == nil {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(k, payload, sig) {
+			return nil
+		}
+	default:
+		return errors.ErrInvalidPublicKey
+	}
+	return errors.ErrSignatureMismatch
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"registry-cli/pkg/errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestVerifySimpleSigning(t *testing.T) {
+	subject := digest.FromString("image")
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"127.0.0.1:5000/repo1"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, subject))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := sha256.Sum256(payload)
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecKey, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig := ed25519.Sign(edKey, payload)
+
+	tests := []struct {
+		name      string
+		key       crypto.PublicKey
+		signature []byte
+		subject   digest.Digest
+		err       error
+	}{
+		{name: "ecdsa", key: &ecKey.PublicKey, signature: ecSig, subject: subject},
+		{name: "ed25519", key: edPub, signature: edSig, subject: subject},
+		{name: "wrong key", key: &otherKey.PublicKey, signature: ecSig, subject: subject, err: errors.ErrSignatureMismatch},
+		{name: "no signature", key: &ecKey.PublicKey, subject: subject, err: errors.ErrSignatureMissing},
+		{name: "other image", key: &ecKey.PublicKey, signature: ecSig, subject: digest.FromString("other")},
+	}
+	for _, tt := range tests {
+		ref, err := verifySimpleSigning(tt.key, payload, base64.StdEncoding.EncodeToString(tt.signature), tt.subject)
+		switch {
+		case tt.subject != subject:
+			if err == nil {
+				t.Errorf("%s: expect error for signature of another image", tt.name)
+			}
+		case err != tt.err:
+			t.Errorf("%s: expect error %v, but got %v", tt.name, tt.err, err)
+		case err == nil && ref != "127.0.0.1:5000/repo1":
+			t.Errorf("%s: expect reference 127.0.0.1:5000/repo1, but got %s", tt.name, ref)
+		}
+	}
+}
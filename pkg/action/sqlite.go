@@ -0,0 +1,256 @@
+package action
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	sqliteHeader        = "SQLite format 3\x00"
+	sqliteHeaderSize    = 100
+	sqliteInteriorTable = 0x05
+	sqliteLeafTable     = 0x0d
+	sqliteMaxDepth      = 64
+)
+
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int
+}
+
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < sqliteHeaderSize || !bytes.HasPrefix(data, []byte(sqliteHeader)) {
+		return nil, fmt.Errorf("not a sqlite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid sqlite page size %d", pageSize)
+	}
+	return &sqliteDB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+	}, nil
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("sqlite page %d out of range", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+func (db *sqliteDB) scanTable(name string, fn func(values []interface{}) error) error {
+	root := 0
+	err := db.walkTable(1, 0, func(values []interface{}) error {
+		if len(values) < 4 {
+			return nil
+		}
+		if typ, _ := values[0].(string); typ != "table" {
+			return nil
+		}
+		if tbl, _ := values[1].(string); tbl != name {
+			return nil
+		}
+		if page, ok := values[3].(int64); ok {
+			root = int(page)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if root == 0 {
+		return fmt.Errorf(`sqlite table "%s" not found`, name)
+	}
+	return db.walkTable(root, 0, fn)
+}
+
+func (db *sqliteDB) walkTable(n, depth int, fn func(values []interface{}) error) error {
+	if depth > sqliteMaxDepth {
+		return fmt.Errorf("sqlite btree too deep")
+	}
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if n == 1 {
+		offset = sqliteHeaderSize
+	}
+	header := page[offset:]
+	cells := int(binary.BigEndian.Uint16(header[3:5]))
+	headerSize := 8
+	if header[0] == sqliteInteriorTable {
+		headerSize = 12
+	}
+	pointers := header[headerSize:]
+	if len(pointers) < cells*2 {
+		return fmt.Errorf("sqlite page %d has too many cells", n)
+	}
+
+	for i := 0; i < cells; i++ {
+		cell := int(binary.BigEndian.Uint16(pointers[i*2:]))
+		if cell >= len(page) {
+			return fmt.Errorf("sqlite page %d has invalid cell offset", n)
+		}
+		switch header[0] {
+		case sqliteInteriorTable:
+			if cell+4 > len(page) {
+				return fmt.Errorf("sqlite page %d has invalid cell", n)
+			}
+			if err := db.walkTable(int(binary.BigEndian.Uint32(page[cell:])), depth+1, fn); err != nil {
+				return err
+			}
+		case sqliteLeafTable:
+			payload, err := db.cellPayload(page, cell)
+			if err != nil {
+				return err
+			}
+			values, err := parseSQLiteRecord(payload)
+			if err != nil {
+				return err
+			}
+			if err := fn(values); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("sqlite page %d is not a table btree page", n)
+		}
+	}
+	if header[0] == sqliteInteriorTable {
+		return db.walkTable(int(binary.BigEndian.Uint32(header[8:12])), depth+1, fn)
+	}
+	return nil
+}
+
+func (db *sqliteDB) cellPayload(page []byte, cell int) ([]byte, error) {
+	size, n := sqliteVarint(page[cell:])
+	cell += n
+	_, n = sqliteVarint(page[cell:])
+	cell += n
+	if size < 0 || size > int64(len(db.data)) {
+		return nil, fmt.Errorf("sqlite cell has invalid payload size %d", size)
+	}
+
+	maxLocal := db.usable - 35
+	local := int(size)
+	if local > maxLocal {
+		minLocal := (db.usable-12)*32/255 - 23
+		local = minLocal + (int(size)-minLocal)%(db.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if cell+local > len(page) {
+		return nil, fmt.Errorf("sqlite cell overflows page")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[cell:cell+local]...)
+	if int64(local) == size {
+		return payload, nil
+	}
+
+	if cell+local+4 > len(page) {
+		return nil, fmt.Errorf("sqlite cell overflows page")
+	}
+	next := int(binary.BigEndian.Uint32(page[cell+local:]))
+	for visited := 0; next != 0 && int64(len(payload)) < size; visited++ {
+		if visited*db.pageSize > len(db.data) {
+			return nil, fmt.Errorf("sqlite overflow chain loops")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		remain := int(size) - len(payload)
+		if remain > db.usable-4 {
+			remain = db.usable - 4
+		}
+		payload = append(payload, overflow[4:4+remain]...)
+		next = int(binary.BigEndian.Uint32(overflow[:4]))
+	}
+	if int64(len(payload)) != size {
+		return nil, fmt.Errorf("sqlite payload is truncated")
+	}
+	return payload, nil
+}
+
+func sqliteVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return int64(v), len(b)
+}
+
+func parseSQLiteRecord(payload []byte) ([]interface{}, error) {
+	headerSize, n := sqliteVarint(payload)
+	if headerSize > int64(len(payload)) || headerSize < int64(n) {
+		return nil, fmt.Errorf("invalid sqlite record header")
+	}
+	var types []int64
+	for i := n; i < int(headerSize); {
+		t, n := sqliteVarint(payload[i:headerSize])
+		types = append(types, t)
+		i += n
+	}
+
+	body := payload[headerSize:]
+	values := make([]interface{}, 0, len(types))
+	for _, t := range types {
+		size := 0
+		switch {
+		case t >= 1 && t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = int(t-12) / 2
+		}
+		if size > len(body) {
+			return nil, fmt.Errorf("sqlite record is truncated")
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t >= 1 && t <= 6:
+			v := int64(int8(field[0]))
+			for _, b := range field[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case t == 8:
+			values = append(values, int64(0))
+		case t == 9:
+			values = append(values, int64(1))
+		case t >= 12 && t%2 == 0:
+			values = append(values, field)
+		case t >= 13:
+			values = append(values, string(field))
+		default:
+			values = append(values, nil)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,84 @@
+package action
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteScanTable(t *testing.T) {
+	data, err := os.ReadFile("testdata/rpmdb.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corruptCell := func(first byte) []byte {
+		corrupt := append([]byte{}, data...)
+		cell := int(binary.BigEndian.Uint16(corrupt[sqliteHeaderSize+8:]))
+		corrupt[cell] = first
+		for i := 1; i < 9; i++ {
+			corrupt[cell+i] = 0xff
+		}
+		return corrupt
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		table string
+		rows  int
+		err   string
+	}{
+		{name: "interior and overflow pages", data: data, table: "Packages", rows: 40},
+		{name: "index table", data: data, table: "Name", rows: 40},
+		{name: "missing table", data: data, table: "Basenames", err: `sqlite table "Basenames" not found`},
+		{name: "not sqlite", data: []byte(strings.Repeat("x", sqliteHeaderSize)), table: "Packages", err: "not a sqlite database"},
+		{name: "truncated", data: data[:3*512], table: "Packages", err: "out of range"},
+		{name: "negative payload size", data: corruptCell(0xff), table: "Packages", err: "invalid payload size"},
+		{name: "huge payload size", data: corruptCell(0x87), table: "Packages", err: "invalid payload size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := 0
+			db, err := openSQLite(tt.data)
+			if err == nil {
+				err = db.scanTable(tt.table, func(values []interface{}) error {
+					rows++
+					return nil
+				})
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expect error %q, but got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows != tt.rows {
+				t.Errorf("expect %d rows, but got %d", tt.rows, rows)
+			}
+		})
+	}
+}
+
+func TestSQLiteVarint(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		value int64
+		size  int
+	}{
+		{data: []byte{0x00}, value: 0, size: 1},
+		{data: []byte{0x7f}, value: 127, size: 1},
+		{data: []byte{0x81, 0x00}, value: 128, size: 2},
+		{data: []byte{0x82, 0x80, 0x01}, value: 0x8001, size: 3},
+		{data: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, value: -1, size: 9},
+	}
+	for _, tt := range tests {
+		if value, size := sqliteVarint(tt.data); value != tt.value || size != tt.size {
+			t.Errorf("%x: expect %d of %d bytes, but got %d of %d bytes", tt.data, tt.value, tt.size, value, size)
+		}
+	}
+}
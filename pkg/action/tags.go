@@ -3,11 +3,13 @@ package action
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"registry-cli/pkg/client"
 	"registry-cli/pkg/errors"
 	"registry-cli/pkg/option"
 	"registry-cli/pkg/output"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,13 +25,21 @@ import (
 
 const maxWorkers = 100
 
+var artifactTagPattern = regexp.MustCompile(`^(sha256)-([a-f0-9]{64})(\.sig|\.att|\.sbom)?$`)
+
 type tagInfo struct {
-	Tag      string     `json:"tag"`
-	Platform string     `json:"platform"`
-	Size     *int64     `json:"size"`
-	Created  *time.Time `json:"created"`
-	Type     string     `json:"type"`
-	Digest   string     `json:"digest"`
+	Tag        string     `json:"tag"`
+	Platform   string     `json:"platform"`
+	Size       *int64     `json:"size"`
+	Created    *time.Time `json:"created"`
+	Type       string     `json:"type"`
+	Digest     string     `json:"digest"`
+	ListDigest string     `json:"listDigest,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	OS         string     `json:"os,omitempty"`
+	OSVersion  string     `json:"osVersion,omitempty"`
+	Base       string     `json:"base,omitempty"`
+	Artifacts  []tagInfo  `json:"artifacts,omitempty"`
 }
 
 func (t tagInfo) Header(opts *option.Options) []string {
@@ -40,6 +50,9 @@ func (t tagInfo) Header(opts *option.Options) []string {
 	if opts.ShowDigest {
 		headers = append(headers, "DIGEST")
 	}
+	if opts.ShowBase {
+		headers = append(headers, "OS", "BASE")
+	}
 	return headers
 }
 
@@ -54,6 +67,9 @@ func (t *tagInfo) Column(opts *option.Options) []string {
 	if opts.ShowDigest {
 		col = append(col, t.Digest)
 	}
+	if opts.ShowBase {
+		col = append(col, orDash(strings.TrimSpace(t.OS+" "+t.OSVersion)), orDash(t.Base))
+	}
 	return col
 }
 
@@ -90,6 +106,17 @@ func Tags(opts *option.Options) error {
 		opts.WriteDebug("get tags", err)
 		return err
 	}
+	if _, explicit := opts.SelectedPlatform(); explicit && n > 0 && len(tags) == 0 {
+		opts.WriteDebug(fmt.Sprintf(`select platform %s in "%s"`, opts.Platform, opts.Repositiory), errors.ErrPlatformNotFound)
+		return errors.ErrPlatformNotFound
+	}
+
+	if opts.ShowBase {
+		if err := fillBaseInfo(opts, cli, tags); err != nil {
+			opts.WriteDebug("get base images", err)
+			return err
+		}
+	}
 
 	if err := outputTags(opts, n, tags); err != nil {
 		opts.WriteDebug("output tags", err)
@@ -100,33 +127,13 @@ func Tags(opts *option.Options) error {
 }
 
 func outputTags(opts *option.Options, num int, tags []tagInfo) error {
-	switch opts.Sort {
-	case option.SortByTag:
-		sort.SliceStable(tags, func(i, j int) bool {
-			return tags[i].Tag < tags[j].Tag
-		})
-	case option.SortBySize:
-		sort.SliceStable(tags, func(i, j int) bool {
-			if tags[i].Size == nil {
-				return true
-			}
-			if tags[j].Size == nil {
-				return false
-			}
-			return (*tags[i].Size) < (*tags[j].Size)
-		})
-	case option.SortByCreated:
-		sort.SliceStable(tags, func(i, j int) bool {
-			if tags[i].Created == nil {
-				return true
-			}
-			if tags[j].Created == nil {
-				return false
-			}
-			return (*tags[i].Created).Before(*tags[j].Created)
-		})
-	default:
-		return errors.ErrUnknownSort
+	if err := sortTags(tags, opts.Sort); err != nil {
+		return err
+	}
+
+	artifactTags := 0
+	if !opts.ShowArtifacts {
+		tags, artifactTags = groupArtifactTags(tags)
 	}
 
 	repoInfo := repoInfo{
@@ -135,7 +142,7 @@ func outputTags(opts *option.Options, num int, tags []tagInfo) error {
 			Summary: summary{
 				Platforms: map[string]*sum{},
 				Sum: sum{
-					Tags: num,
+					Tags: num - artifactTags,
 				},
 			},
 		},
@@ -143,6 +150,9 @@ func outputTags(opts *option.Options, num int, tags []tagInfo) error {
 	}
 
 	for _, tag := range tags {
+		if tag.Subject != "" {
+			continue
+		}
 		size := int64(0)
 		if tag.Size != nil {
 			size = *tag.Size
@@ -172,6 +182,12 @@ func outputTags(opts *option.Options, num int, tags []tagInfo) error {
 			if err := w.Write(tag.Column(opts)...); err != nil {
 				return err
 			}
+			for _, artifact := range tag.Artifacts {
+				artifact.Tag, artifact.Platform = "  └─ "+artifact.Tag, "-"
+				if err := w.Write(artifact.Column(opts)...); err != nil {
+					return err
+				}
+			}
 		}
 		if err := w.Flush(); err != nil {
 			return err
@@ -191,7 +207,98 @@ func outputTags(opts *option.Options, num int, tags []tagInfo) error {
 	return nil
 }
 
+func sortTags(tags []tagInfo, method string) error {
+	switch method {
+	case option.SortByTag:
+		sort.SliceStable(tags, func(i, j int) bool {
+			return tags[i].Tag < tags[j].Tag
+		})
+	case option.SortBySize:
+		sort.SliceStable(tags, func(i, j int) bool {
+			if tags[i].Size == nil {
+				return true
+			}
+			if tags[j].Size == nil {
+				return false
+			}
+			return (*tags[i].Size) < (*tags[j].Size)
+		})
+	case option.SortByCreated:
+		sort.SliceStable(tags, func(i, j int) bool {
+			if tags[i].Created == nil {
+				return true
+			}
+			if tags[j].Created == nil {
+				return false
+			}
+			return (*tags[i].Created).Before(*tags[j].Created)
+		})
+	default:
+		return errors.ErrUnknownSort
+	}
+	return nil
+}
+
+func artifactSubject(tag string) string {
+	m := artifactTagPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	return m[1] + ":" + m[2]
+}
+
+func groupArtifactTags(tags []tagInfo) ([]tagInfo, int) {
+	var images []tagInfo
+	artifacts := map[string][]tagInfo{}
+	var subjects []string
+	artifactTags := map[string]bool{}
+	for _, tag := range tags {
+		subject := artifactSubject(tag.Tag)
+		if subject == "" {
+			images = append(images, tag)
+			continue
+		}
+		tag.Subject = subject
+		if artifacts[subject] == nil {
+			subjects = append(subjects, subject)
+		}
+		artifacts[subject] = append(artifacts[subject], tag)
+		artifactTags[tag.Tag] = true
+	}
+
+	for _, subject := range subjects {
+		var rows []int
+		rowOfTag := map[string]int{}
+		for i := range images {
+			if images[i].Digest != subject && images[i].ListDigest != subject {
+				continue
+			}
+			if _, ok := rowOfTag[images[i].Tag]; !ok {
+				rows = append(rows, i)
+			}
+			rowOfTag[images[i].Tag] = i
+		}
+		if len(rows) == 0 {
+			images = append(images, artifacts[subject]...)
+			continue
+		}
+		for _, i := range rows {
+			row := rowOfTag[images[i].Tag]
+			images[row].Artifacts = append(images[row].Artifacts, artifacts[subject]...)
+		}
+	}
+	return images, len(artifactTags)
+}
+
 func getTags(opts *option.Options, cli *client.Client) (int, []tagInfo, error) {
+	return fetchTags(opts, cli, false)
+}
+
+func getAllTags(opts *option.Options, cli *client.Client) (int, []tagInfo, error) {
+	return fetchTags(opts, cli, true)
+}
+
+func fetchTags(opts *option.Options, cli *client.Client, strict bool) (int, []tagInfo, error) {
 	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
 	if err != nil {
 		opts.WriteDebug("init repository service", err)
@@ -224,9 +331,16 @@ func getTags(opts *option.Options, cli *client.Client) (int, []tagInfo, error) {
 	wg.Add(len(tags))
 
 	var tagInfos []tagInfo
+	var failed []error
+	failedLock := sync.Mutex{}
+	onError := func(tag string, err error) {
+		failedLock.Lock()
+		defer failedLock.Unlock()
+		failed = append(failed, fmt.Errorf(`fetch tag "%s": %w`, tag, err))
+	}
 	resultCh := make(chan *tagInfo)
 	for i := 0; i < numParellel; i++ {
-		go fetchWorker(opts, repo, manifestService, &wg, inputCh, resultCh, stop)
+		go fetchWorker(opts, repo, manifestService, &wg, inputCh, resultCh, stop, onError)
 	}
 	go func() {
 		for _, tag := range tags {
@@ -240,6 +354,9 @@ func getTags(opts *option.Options, cli *client.Client) (int, []tagInfo, error) {
 	close(resultCh)
 	<-collectStopped
 
+	if strict && len(failed) > 0 {
+		return 0, nil, failed[0]
+	}
 	return len(tags), tagInfos, nil
 }
 
@@ -261,7 +378,8 @@ func fetchWorker(
 	wg *sync.WaitGroup,
 	inputCh <-chan string,
 	resultCh chan<- *tagInfo,
-	stop <-chan bool) {
+	stop <-chan bool,
+	onError func(tag string, err error)) {
 
 	for {
 		select {
@@ -269,6 +387,7 @@ func fetchWorker(
 			infos, err := fetchTagInfos(opts, repo, manifestService, tag)
 			if err != nil {
 				opts.WriteDebug(fmt.Sprintf(`fetch get info for "%s"`, tag), err)
+				onError(tag, err)
 			} else {
 				for _, info := range infos {
 					resultCh <- info
@@ -296,29 +415,75 @@ func fetchTagInfos(
 	var r []*tagInfo
 	switch realMan := man.(type) {
 	case *manifestlist.DeserializedManifestList:
-		for _, ref := range realMan.Manifests {
-			man, err := manifestService.Get(opts.Ctx, ref.Digest, registryclient.ReturnContentDigest(&dgst))
-			if err != nil {
-				opts.WriteDebug(fmt.Sprintf(`get manifest for list "%s"'s "%s"`, tag, ref.Digest), err)
-				return nil, err
+		return fetchListTagInfos(opts, repo, manifestService, tag, dgst, realMan, map[digest.Digest]bool{})
+	default:
+		info, err := getManifestInfo(opts, repo, man)
+		if err != nil {
+			return nil, err
+		}
+		info.Tag = tag
+		info.Digest = dgst.String()
+		r = append(r, info)
+	}
+	return r, nil
+}
+
+func fetchListTagInfos(
+	opts *option.Options,
+	repo distribution.Repository,
+	manifestService distribution.ManifestService,
+	tag string,
+	listDigest digest.Digest,
+	list *manifestlist.DeserializedManifestList,
+	seen map[digest.Digest]bool) ([]*tagInfo, error) {
+
+	var r []*tagInfo
+	platform, explicit := opts.SelectedPlatform()
+	refs := list.Manifests
+	if explicit {
+		refs = platformCandidates(platform, explicit, list.Manifests)
+	}
+	for _, ref := range refs {
+		if explicit && len(r) > 0 {
+			break
+		}
+		if seen[ref.Digest] {
+			continue
+		}
+		seen[ref.Digest] = true
+		var dgst digest.Digest
+		man, err := manifestService.Get(opts.Ctx, ref.Digest, registryclient.ReturnContentDigest(&dgst))
+		if err != nil {
+			opts.WriteDebug(fmt.Sprintf(`get manifest for list "%s"'s "%s"`, tag, ref.Digest), err)
+			return nil, err
+		}
+		if child, ok := man.(*manifestlist.DeserializedManifestList); ok {
+			infos, err := fetchListTagInfos(opts, repo, manifestService, tag, listDigest, child, seen)
+			if err == errors.ErrPlatformNotFound {
+				continue
 			}
-			info, err := getManifestInfo(opts, repo, man)
 			if err != nil {
 				return nil, err
 			}
-			info.Tag = tag
-			info.Digest = dgst.String()
-			r = append(r, info)
+			r = append(r, infos...)
+			continue
 		}
-	default:
 		info, err := getManifestInfo(opts, repo, man)
 		if err != nil {
 			return nil, err
 		}
+		if info.Platform == "" && ref.Platform.OS != "" {
+			info.Platform = fmt.Sprintf("%s/%s", ref.Platform.OS, ref.Platform.Architecture)
+		}
 		info.Tag = tag
 		info.Digest = dgst.String()
+		info.ListDigest = listDigest.String()
 		r = append(r, info)
 	}
+	if explicit && len(r) == 0 {
+		opts.WriteDebug(fmt.Sprintf(`select platform %s in list of "%s"`, platform, tag), errors.ErrPlatformNotFound)
+		return nil, errors.ErrPlatformNotFound
+	}
 	return r, nil
 }
 
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"registry-cli/pkg/option"
+	"testing"
+)
+
+func TestGroupArtifactTags(t *testing.T) {
+	const (
+		image = "sha256:502792f92aa92d69975a7df7f1e8b8659b0b8cf8c86ee7dca09eb190d94696c4"
+		list  = "sha256:a068e2a27789e991f2f2f5ecb7b65dd241d761e198abd98e14833ca191d01398"
+		other = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	)
+	tags := []tagInfo{
+		{Tag: "multi", Digest: "sha256:amd64", ListDigest: list},
+		{Tag: "multi", Digest: "sha256:arm64", ListDigest: list},
+		{Tag: "sha256-1111111111111111111111111111111111111111111111111111111111111111.sig", Digest: "sha256:s3"},
+		{Tag: "sha256-502792f92aa92d69975a7df7f1e8b8659b0b8cf8c86ee7dca09eb190d94696c4.att", Digest: "sha256:s2"},
+		{Tag: "sha256-502792f92aa92d69975a7df7f1e8b8659b0b8cf8c86ee7dca09eb190d94696c4.sig", Digest: "sha256:s1"},
+		{Tag: "sha256-a068e2a27789e991f2f2f5ecb7b65dd241d761e198abd98e14833ca191d01398.sig", Digest: "sha256:s4"},
+		{Tag: "sha256-not-a-digest.sig", Digest: "sha256:x"},
+		{Tag: "v1.0", Digest: image},
+	}
+	images, n := groupArtifactTags(tags)
+	if n != 4 {
+		t.Errorf("expect 4 artifact tags, but got %d", n)
+	}
+
+	expect := []struct {
+		tag       string
+		subject   string
+		artifacts int
+	}{
+		{tag: "multi"},
+		{tag: "multi", artifacts: 1},
+		{tag: "sha256-not-a-digest.sig"},
+		{tag: "v1.0", artifacts: 2},
+		{tag: "sha256-1111111111111111111111111111111111111111111111111111111111111111.sig", subject: other},
+	}
+	if len(images) != len(expect) {
+		t.Fatalf("expect %d rows, but got %d", len(expect), len(images))
+	}
+	for i, e := range expect {
+		if images[i].Tag != e.tag || images[i].Subject != e.subject || len(images[i].Artifacts) != e.artifacts {
+			t.Errorf("row %d: expect %+v, but got tag %s subject %s with %d artifacts", i, e, images[i].Tag, images[i].Subject, len(images[i].Artifacts))
+		}
+	}
+}
+
+func TestGroupArtifactTagsSortedBySize(t *testing.T) {
+	const list = "sha256:a068e2a27789e991f2f2f5ecb7b65dd241d761e198abd98e14833ca191d01398"
+	size := func(n int64) *int64 { return &n }
+	tags := []tagInfo{
+		{Tag: "multi", Digest: "sha256:amd64", ListDigest: list, Size: size(100)},
+		{Tag: "multi", Digest: "sha256:arm64", ListDigest: list, Size: size(300)},
+		{Tag: "sha256-a068e2a27789e991f2f2f5ecb7b65dd241d761e198abd98e14833ca191d01398.sig", Digest: "sha256:s1", Size: size(50)},
+		{Tag: "latest", Digest: "sha256:amd64", ListDigest: list, Size: size(100)},
+		{Tag: "v1.0", Digest: "sha256:other", Size: size(200)},
+	}
+	if err := sortTags(tags, option.SortBySize); err != nil {
+		t.Fatal(err)
+	}
+	images, n := groupArtifactTags(tags)
+	if n != 1 {
+		t.Errorf("expect 1 artifact tag, but got %d", n)
+	}
+
+	attached := map[string]int{}
+	for _, image := range images {
+		attached[image.Tag] += len(image.Artifacts)
+	}
+	if len(images) != 4 || attached["multi"] != 1 || attached["latest"] != 1 || attached["v1.0"] != 0 {
+		t.Errorf("expect the signature attached once to multi and latest, but got %v in %d rows", attached, len(images))
+	}
+}
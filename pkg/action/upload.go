@@ -0,0 +1,175 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	registryclient "github.com/distribution/distribution/registry/client"
+	"github.com/docker/distribution"
+	registryapiv2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/opencontainers/go-digest"
+)
+
+type verifiedReader struct {
+	reader   io.Reader
+	verifier digest.Verifier
+	desc     distribution.Descriptor
+	n        int64
+}
+
+func newVerifiedReader(reader io.Reader, desc distribution.Descriptor) *verifiedReader {
+	return &verifiedReader{
+		reader:   io.LimitReader(reader, desc.Size+1),
+		verifier: desc.Digest.Verifier(),
+		desc:     desc,
+	}
+}
+
+func (r *verifiedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	r.verifier.Write(p[:n])
+	return n, err
+}
+
+func (r *verifiedReader) Verify() error {
+	if r.n != r.desc.Size {
+		return fmt.Errorf(`blob "%s" expect %d bytes, but got %d: %w`, r.desc.Digest, r.desc.Size, r.n, errors.ErrSizeMismatch)
+	}
+	if !r.verifier.Verified() {
+		return fmt.Errorf(`blob "%s": %w`, r.desc.Digest, errors.ErrDigestMismatch)
+	}
+	return nil
+}
+
+func uploadBlob(opts *option.Options, writer distribution.BlobWriter, reader io.Reader, desc distribution.Descriptor) error {
+	verified := newVerifiedReader(reader, desc)
+	n, err := writer.ReadFrom(verified)
+	if err == nil {
+		err = verified.Verify()
+	}
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`upload blob "%s"`, desc.Digest), err)
+		writer.Cancel(opts.Ctx)
+		return err
+	}
+	if _, err := writer.Commit(opts.Ctx, desc); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`commit blob "%s"`, desc.Digest), err)
+		return err
+	}
+	opts.WriteDebug(fmt.Sprintf(`upload blob "%s" %d bytes`, desc.Digest, n), nil)
+	return nil
+}
+
+func uploadBlobChunked(opts *option.Options, cli *client.Client, repo distribution.Repository, reader io.Reader, desc distribution.Descriptor, chunkSize int64) error {
+	roundTripper, err := cli.GetRoundTripper(repo.Named().Name(), client.PullAction, client.PushAction)
+	if err != nil {
+		return err
+	}
+	ub, err := registryapiv2.NewURLBuilderFromString(cli.GetBaseURL(), false)
+	if err != nil {
+		return err
+	}
+	u, err := ub.BuildBlobUploadURL(repo.Named())
+	if err != nil {
+		return err
+	}
+
+	location, err := doUploadRequest(opts, roundTripper, http.MethodPost, u, nil, nil)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`start upload for blob "%s"`, desc.Digest), err)
+		return err
+	}
+
+	verified := newVerifiedReader(reader, desc)
+	buf := make([]byte, chunkSize)
+	offset := int64(0)
+	for {
+		n, readErr := io.ReadFull(verified, buf)
+		if n > 0 {
+			header := http.Header{}
+			header.Set("Content-Type", "application/octet-stream")
+			header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(n)-1))
+			location, err = doUploadRequest(opts, roundTripper, http.MethodPatch, location, header, buf[:n])
+			if err != nil {
+				opts.WriteDebug(fmt.Sprintf(`upload chunk %d-%d for blob "%s"`, offset, offset+int64(n)-1, desc.Digest), err)
+				cancelUpload(opts, roundTripper, location)
+				return err
+			}
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			cancelUpload(opts, roundTripper, location)
+			return readErr
+		}
+	}
+	if err := verified.Verify(); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`upload blob "%s"`, desc.Digest), err)
+		cancelUpload(opts, roundTripper, location)
+		return err
+	}
+
+	commitURL, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	values := commitURL.Query()
+	values.Set("digest", desc.Digest.String())
+	commitURL.RawQuery = values.Encode()
+	if _, err := doUploadRequest(opts, roundTripper, http.MethodPut, commitURL.String(), nil, nil); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`commit blob "%s"`, desc.Digest), err)
+		return err
+	}
+	opts.WriteDebug(fmt.Sprintf(`upload blob "%s" %d bytes in chunks`, desc.Digest, offset), nil)
+	return nil
+}
+
+func doUploadRequest(opts *option.Options, roundTripper http.RoundTripper, method, u string, header http.Header, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(opts.Ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.ContentLength = int64(len(body))
+
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if !registryclient.SuccessStatus(resp.StatusCode) {
+		return "", registryclient.HandleErrorResponse(resp)
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return u, nil
+	}
+	base, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+func cancelUpload(opts *option.Options, roundTripper http.RoundTripper, location string) {
+	if _, err := doUploadRequest(opts, roundTripper, http.MethodDelete, location, nil, nil); err != nil {
+		opts.WriteDebug(fmt.Sprintf(`cancel upload "%s"`, location), err)
+	}
+}
@@ -0,0 +1,229 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"registry-cli/pkg/client"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"registry-cli/pkg/output"
+
+	"github.com/distribution/distribution/manifest/manifestlist"
+	"github.com/distribution/distribution/manifest/schema1"
+	"github.com/docker/distribution"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	verifyStatusOK             = "ok"
+	verifyStatusMissing        = "missing"
+	verifyStatusSizeMismatch   = "size-mismatch"
+	verifyStatusDigestMismatch = "digest-mismatch"
+)
+
+type blobStatus struct {
+	Manifest  string `json:"manifest"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Status    string `json:"status"`
+}
+
+func (b blobStatus) Header() []string {
+	return []string{"MANIFEST", "DIGEST", "TYPE", "SIZE", "STATUS"}
+}
+
+func (b *blobStatus) Column() []string {
+	return []string{b.Manifest, b.Digest, b.MediaType, output.SizeToShow(&b.Size), b.Status}
+}
+
+type verifyResult struct {
+	Repository string       `json:"repository"`
+	Digest     string       `json:"digest"`
+	Blobs      []blobStatus `json:"blobs"`
+}
+
+func (v *verifyResult) Output(opts *option.Options) error {
+	switch opts.Output {
+	case option.JSONOutput:
+		return output.WriteJSON(opts.StdOut, v)
+	case option.TextOutput:
+		w, err := output.NewTextWriter(opts.StdOut, blobStatus{}.Header()...)
+		if err != nil {
+			return err
+		}
+		for _, b := range v.Blobs {
+			if err := w.Write(b.Column()...); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
+	return errors.ErrUnknownOutput
+}
+
+func Verify(opts *option.Options) error {
+	cli, err := client.NewClient(opts)
+	if err != nil {
+		opts.WriteDebug("init client", err)
+		return err
+	}
+	repo, err := cli.NewRepository(opts.Repositiory, client.PullAction)
+	if err != nil {
+		opts.WriteDebug("init repository service", err)
+		return err
+	}
+	manifestService, err := repo.Manifests(opts.Ctx)
+	if err != nil {
+		opts.WriteDebug("init manifest service", err)
+		return err
+	}
+
+	man, err := fetchManifest(opts, manifestService)
+	if err != nil {
+		opts.WriteDebug("fetch manifest", err)
+		return err
+	}
+
+	v := &verifier{
+		opts:            opts,
+		repo:            repo,
+		manifestService: manifestService,
+		verified:        map[digest.Digest]bool{},
+		result: &verifyResult{
+			Repository: opts.Repositiory,
+			Digest:     opts.Digest.String(),
+		},
+	}
+	if err := v.verifyManifest(man, opts.Digest); err != nil {
+		return err
+	}
+
+	if err := v.result.Output(opts); err != nil {
+		opts.WriteDebug("output verify result", err)
+		return err
+	}
+	for _, b := range v.result.Blobs {
+		if b.Status != verifyStatusOK {
+			return errors.ErrVerifyFailed
+		}
+	}
+	return nil
+}
+
+type verifier struct {
+	opts            *option.Options
+	repo            distribution.Repository
+	manifestService distribution.ManifestService
+	verified        map[digest.Digest]bool
+	result          *verifyResult
+}
+
+func (v *verifier) verifyManifest(man distribution.Manifest, dgst digest.Digest) error {
+	mediaType, payload, err := man.Payload()
+	if err != nil {
+		v.opts.WriteDebug(fmt.Sprintf(`get payload of manifest "%s"`, dgst), err)
+		return err
+	}
+	canonical := payload
+	if signed, ok := man.(*schema1.SignedManifest); ok {
+		canonical = signed.Canonical
+	}
+	status := verifyStatusOK
+	if digest.FromBytes(canonical) != dgst {
+		status = verifyStatusDigestMismatch
+	}
+	v.result.Blobs = append(v.result.Blobs, blobStatus{
+		Manifest:  dgst.String(),
+		Digest:    dgst.String(),
+		MediaType: mediaType,
+		Size:      int64(len(payload)),
+		Status:    status,
+	})
+
+	if list, ok := man.(*manifestlist.DeserializedManifestList); ok {
+		for _, ref := range list.Manifests {
+			child, err := v.manifestService.Get(v.opts.Ctx, ref.Digest)
+			if isManifestUnknown(err) {
+				v.result.Blobs = append(v.result.Blobs, blobStatus{
+					Manifest:  dgst.String(),
+					Digest:    ref.Digest.String(),
+					MediaType: ref.MediaType,
+					Size:      ref.Size,
+					Status:    verifyStatusMissing,
+				})
+				continue
+			}
+			if err != nil {
+				v.opts.WriteDebug(fmt.Sprintf(`fetch manifest "%s"`, ref.Digest), err)
+				return err
+			}
+			if err := v.verifyManifest(child, ref.Digest); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, desc := range man.References() {
+		if v.verified[desc.Digest] {
+			continue
+		}
+		v.verified[desc.Digest] = true
+		status, err := v.verifyBlob(desc)
+		if err != nil {
+			return err
+		}
+		v.result.Blobs = append(v.result.Blobs, blobStatus{
+			Manifest:  dgst.String(),
+			Digest:    desc.Digest.String(),
+			MediaType: desc.MediaType,
+			Size:      desc.Size,
+			Status:    status,
+		})
+	}
+	return nil
+}
+
+func (v *verifier) verifyBlob(desc distribution.Descriptor) (string, error) {
+	blobs := v.repo.Blobs(v.opts.Ctx)
+	if desc.Size == 0 {
+		stat, err := blobs.Stat(v.opts.Ctx, desc.Digest)
+		if err == distribution.ErrBlobUnknown {
+			return verifyStatusMissing, nil
+		}
+		if err != nil {
+			v.opts.WriteDebug(fmt.Sprintf(`stat blob "%s"`, desc.Digest), err)
+			return "", err
+		}
+		desc.Size = stat.Size
+	}
+
+	reader, err := blobs.Open(v.opts.Ctx, desc.Digest)
+	if err == distribution.ErrBlobUnknown {
+		return verifyStatusMissing, nil
+	}
+	if err != nil {
+		v.opts.WriteDebug(fmt.Sprintf(`open blob "%s"`, desc.Digest), err)
+		return "", err
+	}
+	defer reader.Close()
+
+	verified := newVerifiedReader(reader, desc)
+	_, err = io.Copy(io.Discard, verified)
+	if err == distribution.ErrBlobUnknown {
+		return verifyStatusMissing, nil
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		v.opts.WriteDebug(fmt.Sprintf(`read blob "%s"`, desc.Digest), err)
+		return "", err
+	}
+	if verified.n != desc.Size {
+		v.opts.WriteDebug(fmt.Sprintf(`blob "%s" expect %d bytes, but got %d`, desc.Digest, desc.Size, verified.n), nil)
+		return verifyStatusSizeMismatch, nil
+	}
+	if !verified.verifier.Verified() {
+		return verifyStatusDigestMismatch, nil
+	}
+	return verifyStatusOK, nil
+}
@@ -0,0 +1,334 @@
+package action
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	ecosystemDebian    = "Debian"
+	ecosystemUbuntu    = "Ubuntu"
+	ecosystemAlpine    = "Alpine"
+	ecosystemRedHat    = "Red Hat"
+	ecosystemRocky     = "Rocky Linux"
+	ecosystemAlmaLinux = "AlmaLinux"
+	ecosystemOpenSUSE  = "openSUSE"
+	ecosystemSUSE      = "SUSE"
+)
+
+func compareVersions(ecosystem, a, b string) int {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	switch base {
+	case ecosystemDebian, ecosystemUbuntu:
+		return compareDpkgVersions(a, b)
+	case ecosystemAlpine:
+		return compareApkVersions(a, b)
+	case ecosystemRedHat, ecosystemRocky, ecosystemAlmaLinux, ecosystemOpenSUSE, ecosystemSUSE:
+		return compareRPMVersions(a, b)
+	}
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA == nil && errB == nil {
+		return va.Compare(vb)
+	}
+	return verrevcmp(strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v"))
+}
+
+func splitEpoch(version string) (int, string) {
+	epoch, rest, ok := strings.Cut(version, ":")
+	if !ok {
+		return 0, version
+	}
+	n, err := strconv.Atoi(epoch)
+	if err != nil {
+		return 0, version
+	}
+	return n, rest
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareDpkgVersions(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if c := compareInts(epochA, epochB); c != 0 {
+		return c
+	}
+	upstreamA, revisionA := a, ""
+	if i := strings.LastIndex(a, "-"); i >= 0 {
+		upstreamA, revisionA = a[:i], a[i+1:]
+	}
+	upstreamB, revisionB := b, ""
+	if i := strings.LastIndex(b, "-"); i >= 0 {
+		upstreamB, revisionB = b[:i], b[i+1:]
+	}
+	if c := verrevcmp(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return verrevcmp(revisionA, revisionB)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func dpkgOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	switch c := s[i]; {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isDigit(a[i]) || j < len(b) && !isDigit(b[j]) {
+			if c := compareInts(dpkgOrder(a, i), dpkgOrder(b, j)); c != 0 {
+				return c
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = compareInts(int(a[i]), int(b[j]))
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+func compareRPMVersions(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if c := compareInts(epochA, epochB); c != 0 {
+		return c
+	}
+	versionA, releaseA, hasReleaseA := strings.Cut(a, "-")
+	versionB, releaseB, hasReleaseB := strings.Cut(b, "-")
+	if c := rpmvercmp(versionA, versionB); c != 0 {
+		return c
+	}
+	if !hasReleaseA || !hasReleaseB {
+		return 0
+	}
+	return rpmvercmp(releaseA, releaseB)
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isAlpha(c)
+}
+
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isAlnum(a[i]) && a[i] != '~' && a[i] != '^' {
+			i++
+		}
+		for j < len(b) && !isAlnum(b[j]) && b[j] != '~' && b[j] != '^' {
+			j++
+		}
+
+		tildeA, tildeB := i < len(a) && a[i] == '~', j < len(b) && b[j] == '~'
+		if tildeA || tildeB {
+			if !tildeA {
+				return 1
+			}
+			if !tildeB {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		caretA, caretB := i < len(a) && a[i] == '^', j < len(b) && b[j] == '^'
+		if caretA || caretB {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if !caretA {
+				return 1
+			}
+			if !caretB {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		startA, startB := i, j
+		numeric := isDigit(a[i])
+		if numeric {
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+		} else {
+			for i < len(a) && isAlpha(a[i]) {
+				i++
+			}
+			for j < len(b) && isAlpha(b[j]) {
+				j++
+			}
+		}
+		segA, segB := a[startA:i], b[startB:j]
+		if segB == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+		if numeric {
+			segA, segB = strings.TrimLeft(segA, "0"), strings.TrimLeft(segB, "0")
+			if c := compareInts(len(segA), len(segB)); c != 0 {
+				return c
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a)-i, len(b)-j)
+}
+
+var apkSuffixes = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+type apkSuffix struct {
+	rank   int
+	number int
+}
+
+type apkVersion struct {
+	numbers  []int
+	letter   byte
+	suffixes []apkSuffix
+	revision int
+}
+
+func parseApkVersion(version string) apkVersion {
+	v := apkVersion{}
+	if i := strings.LastIndex(version, "-r"); i >= 0 {
+		if n, err := strconv.Atoi(version[i+2:]); err == nil {
+			v.revision = n
+			version = version[:i]
+		}
+	}
+	main, suffixes, _ := strings.Cut(version, "_")
+	parts := strings.Split(main, ".")
+	if last := parts[len(parts)-1]; len(last) > 1 && isAlpha(last[len(last)-1]) {
+		v.letter = last[len(last)-1]
+		parts[len(parts)-1] = last[:len(last)-1]
+	}
+	for _, part := range parts {
+		n, _ := strconv.Atoi(part)
+		v.numbers = append(v.numbers, n)
+	}
+	if suffixes == "" {
+		return v
+	}
+	for _, s := range strings.Split(suffixes, "_") {
+		name := strings.TrimRightFunc(s, func(r rune) bool { return r >= '0' && r <= '9' })
+		n, _ := strconv.Atoi(s[len(name):])
+		v.suffixes = append(v.suffixes, apkSuffix{rank: apkSuffixes[name], number: n})
+	}
+	return v
+}
+
+func compareApkVersions(a, b string) int {
+	va, vb := parseApkVersion(a), parseApkVersion(b)
+	for i := 0; i < len(va.numbers) || i < len(vb.numbers); i++ {
+		if i >= len(va.numbers) {
+			return -1
+		}
+		if i >= len(vb.numbers) {
+			return 1
+		}
+		if c := compareInts(va.numbers[i], vb.numbers[i]); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(int(va.letter), int(vb.letter)); c != 0 {
+		return c
+	}
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		sa, sb := apkSuffix{}, apkSuffix{}
+		if i < len(va.suffixes) {
+			sa = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			sb = vb.suffixes[i]
+		}
+		if c := compareInts(sa.rank, sb.rank); c != 0 {
+			return c
+		}
+		if c := compareInts(sa.number, sb.number); c != 0 {
+			return c
+		}
+	}
+	return compareInts(va.revision, vb.revision)
+}
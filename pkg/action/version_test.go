@@ -0,0 +1,38 @@
+package action
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		a, b      string
+		expect    int
+	}{
+		{ecosystem: "Debian:11", a: "1.1.1n-0+deb11u3", b: "1.1.1n-0+deb11u4", expect: -1},
+		{ecosystem: "Debian:11", a: "1:1.2.11.dfsg-2", b: "1.2.13-1", expect: 1},
+		{ecosystem: "Debian:11", a: "1.0~rc1-1", b: "1.0-1", expect: -1},
+		{ecosystem: "Debian:11", a: "1.0-1", b: "1.0-1", expect: 0},
+		{ecosystem: "Ubuntu:22.04", a: "2.34-0ubuntu3.2", b: "2.34-0ubuntu3.10", expect: -1},
+		{ecosystem: "Alpine:v3.17", a: "3.0.8-r0", b: "3.0.8-r1", expect: -1},
+		{ecosystem: "Alpine:v3.17", a: "1.2.3_rc1", b: "1.2.3", expect: -1},
+		{ecosystem: "Alpine:v3.17", a: "1.2.3_p1", b: "1.2.3", expect: 1},
+		{ecosystem: "Alpine:v3.17", a: "1.2.3a", b: "1.2.3", expect: 1},
+		{ecosystem: "Alpine:v3.17", a: "1.2", b: "1.2.1", expect: -1},
+		{ecosystem: "Rocky Linux:8", a: "1:1.1.1k-7.el8_6", b: "1:1.1.1k-9.el8_7", expect: -1},
+		{ecosystem: "Rocky Linux:8", a: "4.4.20-4.el8_6", b: "0:4.4.20-4.el8_6", expect: 0},
+		{ecosystem: "Rocky Linux:8", a: "1.0~rc1-1", b: "1.0-1", expect: -1},
+		{ecosystem: "Rocky Linux:8", a: "1.0a-1", b: "1.0-1", expect: 1},
+		{ecosystem: "Rocky Linux:8", a: "1.10-1", b: "1.9-1", expect: 1},
+		{ecosystem: "npm", a: "4.17.20", b: "4.17.21", expect: -1},
+		{ecosystem: "Go", a: "v0.0.0-20220722155217-630584e8d5aa", b: "v0.1.0", expect: -1},
+		{ecosystem: "PyPI", a: "2.28.1.post1", b: "2.28.2", expect: -1},
+	}
+	for _, tt := range tests {
+		if c := compareVersions(tt.ecosystem, tt.a, tt.b); c != tt.expect {
+			t.Errorf("%s: compare %s with %s, expect %d, but got %d", tt.ecosystem, tt.a, tt.b, tt.expect, c)
+		}
+		if c := compareVersions(tt.ecosystem, tt.b, tt.a); c != -tt.expect {
+			t.Errorf("%s: compare %s with %s, expect %d, but got %d", tt.ecosystem, tt.b, tt.a, -tt.expect, c)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/distribution/distribution/registry/client/auth"
+)
+
+type tokenAccess struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
+
+type basicCredStore struct {
+	auth.CredentialStore
+}
+
+func (cs basicCredStore) RefreshToken(*url.URL, string) string {
+	return ""
+}
+
+func (cs basicCredStore) SetRefreshToken(*url.URL, string, string) {
+}
+
+func (c *Client) CheckAccess(repo string, actions ...Action) (granted bool, checked bool) {
+	endpointURL, err := url.Parse(c.baseURL)
+	if err != nil {
+		return false, false
+	}
+	endpointURL.Path = "/v2/"
+	challenges, err := c.challengeManager.GetChallenges(*endpointURL)
+	if err != nil {
+		c.opts.WriteDebug("get auth challenges", err)
+		return false, false
+	}
+	var params map[string]string
+	for _, ch := range challenges {
+		if strings.EqualFold(ch.Scheme, "bearer") {
+			params = ch.Parameters
+		}
+	}
+	if params == nil {
+		c.opts.WriteDebug("registry does not use token authentication, skip checking access", nil)
+		return false, false
+	}
+
+	names := make([]string, 0, len(actions))
+	for _, action := range actions {
+		names = append(names, string(action))
+	}
+	handler := auth.NewTokenHandlerWithOptions(auth.TokenHandlerOptions{
+		Transport:   c.httpClient.Transport,
+		Credentials: basicCredStore{c.credStore},
+		Scopes:      []auth.Scope{auth.RepositoryScope{Repository: repo, Actions: names}},
+		ClientID:    tokenClientID,
+	})
+	req, err := http.NewRequest(http.MethodGet, endpointURL.String(), nil)
+	if err != nil {
+		return false, false
+	}
+	if err := handler.AuthorizeRequest(req, params); err != nil {
+		c.opts.WriteDebug(fmt.Sprintf(`request token for "%s"`, repo), err)
+		return false, false
+	}
+	access, ok := parseTokenAccess(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	if !ok {
+		c.opts.WriteDebug("token has no access claim, skip checking access", nil)
+		return false, false
+	}
+	return hasAccess(access, repo, names), true
+}
+
+func parseTokenAccess(token string) ([]tokenAccess, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+	claims := struct {
+		Access *[]tokenAccess `json:"access"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Access == nil {
+		return nil, false
+	}
+	return *claims.Access, true
+}
+
+func hasAccess(access []tokenAccess, repo string, actions []string) bool {
+	granted := map[string]bool{}
+	for _, a := range access {
+		if a.Type != "repository" || a.Name != repo {
+			continue
+		}
+		for _, action := range a.Actions {
+			granted[action] = true
+		}
+	}
+	for _, action := range actions {
+		if !granted[action] && !granted["*"] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"registry-cli/pkg/option"
+	"strings"
+	"testing"
+)
+
+func TestCheckAccess(t *testing.T) {
+	var methods []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.URL.Path {
+		case "/token":
+			if user, _, _ := r.BasicAuth(); user == "opaque" {
+				fmt.Fprint(w, `{"token":"opaque"}`)
+				return
+			}
+			claims := `{"access":[{"type":"repository","name":"repo1","actions":["pull"]}]}`
+			if strings.Contains(r.URL.Query().Get("scope"), "repo2") {
+				claims = `{"access":[{"type":"repository","name":"repo2","actions":["*"]}]}`
+			}
+			fmt.Fprintf(w, `{"token":"header.%s.signature"}`, base64.RawURLEncoding.EncodeToString([]byte(claims)))
+		default:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	opts := &option.Options{Server: strings.TrimPrefix(server.URL, "http://"), Username: "u", Password: "p", PlainHTTP: true}
+	cli, err := NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		repo    string
+		action  Action
+		granted bool
+	}{
+		{repo: "repo1", action: PullAction, granted: true},
+		{repo: "repo1", action: DeleteAction},
+		{repo: "repo2", action: PushAction, granted: true},
+	} {
+		if granted, checked := cli.CheckAccess(c.repo, c.action); !checked || granted != c.granted {
+			t.Errorf("%s %s: expect granted %v, but got %v (checked %v)", c.repo, c.action, c.granted, granted, checked)
+		}
+	}
+
+	opaque := *opts
+	opaque.Username = "opaque"
+	if cli, err = NewClient(&opaque); err != nil {
+		t.Fatal(err)
+	}
+	if _, checked := cli.CheckAccess("repo1", PushAction); checked {
+		t.Error("expect not checked for a token without access claim")
+	}
+	basic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer basic.Close()
+	opaque.Server = strings.TrimPrefix(basic.URL, "http://")
+	if cli, err = NewClient(&opaque); err != nil {
+		t.Fatal(err)
+	}
+	if _, checked := cli.CheckAccess("repo1", DeleteAction); checked {
+		t.Error("expect not checked for basic authentication")
+	}
+
+	for _, m := range methods {
+		if m != http.MethodGet {
+			t.Errorf("expect only GET requests, but got %s", m)
+		}
+	}
+}
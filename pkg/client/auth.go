@@ -17,6 +17,7 @@ type credstore struct {
 	username      string
 	password      string
 	auth          string
+	identityToken string
 	refreshTokens map[string]string
 }
 
@@ -25,7 +26,10 @@ func (cs *credstore) Basic(*url.URL) (string, string) {
 }
 
 func (cs *credstore) RefreshToken(u *url.URL, service string) string {
-	return cs.refreshTokens[service]
+	if token, ok := cs.refreshTokens[service]; ok {
+		return token
+	}
+	return cs.identityToken
 }
 
 func (cs *credstore) SetRefreshToken(u *url.URL, service string, token string) {
@@ -53,6 +57,10 @@ func decodeAuth(auth string, opts *option.Options) (username, password string) {
 }
 
 func NewCredStore(opts *option.Options) auth.CredentialStore {
+	return newCredStore(opts)
+}
+
+func newCredStore(opts *option.Options) *credstore {
 	cs := credstore{
 		refreshTokens: make(map[string]string),
 	}
@@ -61,7 +69,7 @@ func NewCredStore(opts *option.Options) auth.CredentialStore {
 		cs.auth = opts.Auth
 	} else if opts.Username != "" {
 		cs.username, cs.password, cs.auth = opts.Username, opts.Password, makeAuth(opts.Username, opts.Password)
-	} else {
+	} else if !cs.fromCredHelper(opts) {
 		configs, err := config.GetAllCredentials(&types.SystemContext{})
 		if err != nil {
 			opts.WriteDebug("get system credentials", err)
@@ -83,3 +91,26 @@ func NewCredStore(opts *option.Options) auth.CredentialStore {
 
 	return &cs
 }
+
+func (cs *credstore) fromCredHelper(opts *option.Options) bool {
+	cfg, err := loadDockerConfig()
+	if err != nil {
+		opts.WriteDebug("load docker config", err)
+		return false
+	}
+	helper := cfg.helper(opts.Server)
+	if helper == "" {
+		return false
+	}
+	creds, err := getHelperCredentials(helper, opts.Server)
+	if err != nil {
+		opts.WriteDebug(fmt.Sprintf(`get credentials of "%s" from "%s%s"`, opts.Server, credHelperPrefix, helper), err)
+		return false
+	}
+	if creds.Username == identityTokenUser {
+		cs.identityToken = creds.Secret
+		return true
+	}
+	cs.username, cs.password, cs.auth = creds.Username, creds.Secret, makeAuth(creds.Username, creds.Secret)
+	return true
+}
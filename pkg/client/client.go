@@ -2,7 +2,6 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,14 +17,21 @@ import (
 )
 
 const (
-	bufsize = 50
+	bufsize       = 50
+	tokenClientID = "registrycli"
 )
 
 type RepoHandler func(repoName string) (stop bool, err error)
 
+type Scope struct {
+	Repository string
+	Actions    []Action
+}
+
 type Client struct {
 	challengeManager challenge.Manager
 	credStore        auth.CredentialStore
+	tokenCache       *tokenCache
 	opts             *option.Options
 	baseURL          string
 	httpClient       *http.Client
@@ -38,20 +44,34 @@ func NewClient(opts *option.Options) (*Client, error) {
 	} else {
 		scheme = "https"
 	}
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		opts.WriteDebug("load tls config", err)
+		return nil, err
+	}
 	c := &Client{
 		opts:             opts,
 		challengeManager: challenge.NewSimpleManager(),
-		credStore:        NewCredStore(opts),
 		baseURL:          fmt.Sprintf("%s://%s", scheme, opts.Server),
 		httpClient: &http.Client{
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: opts.Insecure,
-				},
+				TLSClientConfig: tlsConfig,
 			},
 		},
 	}
 
+	cs := newCredStore(opts)
+	c.credStore = cs
+	if opts.TokenCache {
+		cache, err := newTokenCache(opts, cs)
+		if err != nil {
+			opts.WriteDebug("load token cache", err)
+		} else {
+			c.tokenCache = cache
+			c.credStore = &cachedCredStore{CredentialStore: cs, cache: cache, opts: opts}
+		}
+	}
+
 	if err := c.tryEstablishChallenges(); err != nil {
 		opts.WriteDebug("failed to establish challegenes", err)
 		return nil, err
@@ -73,14 +93,18 @@ func (c *Client) NewRegistry() (registryclient.Registry, error) {
 	return registryclient.NewRegistry(c.baseURL, roundTripper)
 }
 
-func (c *Client) NewRepository(repo string, action Action) (distribution.Repository, error) {
+func (c *Client) NewRepository(repo string, actions ...Action) (distribution.Repository, error) {
+	return c.NewRepositoryWithScopes(repo, Scope{Repository: repo, Actions: actions})
+}
+
+func (c *Client) NewRepositoryWithScopes(repo string, scopes ...Scope) (distribution.Repository, error) {
 	repoNamed, err := reference.WithName(repo)
 	if err != nil {
 		c.opts.WriteDebug(fmt.Sprintf(`failed to refer name: "%s"`, repo), err)
 		return nil, err
 	}
 
-	roundTripper, err := c.GetRoundTripper(repoNamed.String(), action)
+	roundTripper, err := c.GetRoundTripperWithScopes(scopes...)
 	if err != nil {
 		c.opts.WriteDebug(fmt.Sprintf(`failed to get round tripper for: "%s"`, repo), err)
 		return nil, err
@@ -122,11 +146,54 @@ func (c *Client) tryEstablishChallenges() error {
 	return nil
 }
 
-func (c *Client) GetRoundTripper(scope string, action Action) (http.RoundTripper, error) {
-	return transport.NewTransport(c.httpClient.Transport,
-		auth.NewAuthorizer(c.challengeManager,
-			auth.NewBasicHandler(c.credStore),
-			auth.NewTokenHandler(c.httpClient.Transport, c.credStore, scope, string(action)))), nil
+func (c *Client) GetRoundTripper(scope string, actions ...Action) (http.RoundTripper, error) {
+	return c.GetRoundTripperWithScopes(Scope{Repository: scope, Actions: actions})
+}
+
+func (c *Client) GetRoundTripperWithScopes(scopes ...Scope) (http.RoundTripper, error) {
+	authScopes := make([]auth.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		actions := make([]string, 0, len(scope.Actions))
+		for _, action := range scope.Actions {
+			actions = append(actions, string(action))
+		}
+		authScopes = append(authScopes, auth.RepositoryScope{
+			Repository: scope.Repository,
+			Actions:    actions,
+		})
+	}
+	tokenHandler := auth.NewTokenHandlerWithOptions(auth.TokenHandlerOptions{
+		Transport:     c.httpClient.Transport,
+		Credentials:   c.credStore,
+		Scopes:        authScopes,
+		OfflineAccess: c.tokenCache != nil,
+		ClientID:      tokenClientID,
+	})
+	if c.tokenCache == nil {
+		return transport.NewTransport(c.httpClient.Transport,
+			auth.NewAuthorizer(c.challengeManager,
+				auth.NewBasicHandler(c.credStore),
+				tokenHandler)), nil
+	}
+
+	scopeNames := make([]string, 0, len(authScopes))
+	for _, scope := range authScopes {
+		scopeNames = append(scopeNames, scope.String())
+	}
+	cachedHandler := &cachedTokenHandler{
+		AuthenticationHandler: tokenHandler,
+		cache:                 c.tokenCache,
+		scopes:                scopeNames,
+		opts:                  c.opts,
+	}
+	authorizer := auth.NewAuthorizer(c.challengeManager,
+		auth.NewBasicHandler(c.credStore),
+		cachedHandler)
+	return transport.NewTransport(&cachedTokenTransport{
+		base:       c.httpClient.Transport,
+		handler:    cachedHandler,
+		authorizer: authorizer,
+	}, authorizer), nil
 }
 
 func (c *Client) WalkAllRepos(ctx context.Context, registry registryclient.Registry, fun RepoHandler) error {
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"sort"
+	"strings"
+)
+
+const (
+	credHelperPrefix     = "docker-credential-"
+	credNotFoundMessage  = "credentials not found in native keychain"
+	identityTokenUser    = "<token>"
+	dockerHubServer      = "docker.io"
+	dockerHubIndexServer = "https://index.docker.io/v1/"
+)
+
+type dockerConfig struct {
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+func dockerConfigFile() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+func loadDockerConfig() (*dockerConfig, error) {
+	cfg := &dockerConfig{}
+	file := dockerConfigFile()
+	if file == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf(`unmarshal "%s": %v`, file, err)
+	}
+	return cfg, nil
+}
+
+func (cfg *dockerConfig) helper(server string) string {
+	for key, helper := range cfg.CredHelpers {
+		if serverHost(key) == server {
+			return helper
+		}
+	}
+	return cfg.CredsStore
+}
+
+func serverHost(server string) string {
+	if server == dockerHubIndexServer {
+		return dockerHubServer
+	}
+	if strings.Contains(server, "://") {
+		if u, err := url.Parse(server); err == nil {
+			return u.Host
+		}
+	}
+	host, _, _ := strings.Cut(server, "/")
+	return host
+}
+
+func runCredHelper(helper, action, input string) ([]byte, error) {
+	cmd := exec.Command(credHelperPrefix+helper, action)
+	cmd.Stdin = strings.NewReader(input)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stdout.String())
+		if message == credNotFoundMessage {
+			return nil, errors.ErrCredentialsNotFound
+		}
+		if message == "" {
+			message = strings.TrimSpace(stderr.String())
+		}
+		return nil, fmt.Errorf(`run "%s%s %s": %v: %s`, credHelperPrefix, helper, action, err, message)
+	}
+	return stdout.Bytes(), nil
+}
+
+func getHelperCredentials(helper, server string) (*helperCredentials, error) {
+	candidates := []string{server}
+	if server == dockerHubServer {
+		candidates = []string{dockerHubIndexServer, server}
+	}
+	creds, err := getHelperCredentialsOf(helper, candidates)
+	if err != errors.ErrCredentialsNotFound {
+		return creds, err
+	}
+
+	candidates, err = listHelperServers(helper, server)
+	if err != nil {
+		return nil, err
+	}
+	return getHelperCredentialsOf(helper, candidates)
+}
+
+func listHelperServers(helper, server string) ([]string, error) {
+	out, err := runCredHelper(helper, "list", "")
+	if err != nil {
+		return nil, err
+	}
+	servers := map[string]string{}
+	if err := json.Unmarshal(out, &servers); err != nil {
+		return nil, fmt.Errorf(`unmarshal output of "%s%s list": %v`, credHelperPrefix, helper, err)
+	}
+	var found []string
+	for key := range servers {
+		if serverHost(key) == server && key != server && key != dockerHubIndexServer {
+			found = append(found, key)
+		}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+func getHelperCredentialsOf(helper string, servers []string) (*helperCredentials, error) {
+	for _, server := range servers {
+		out, err := runCredHelper(helper, "get", server)
+		if err == errors.ErrCredentialsNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		creds := &helperCredentials{}
+		if err := json.Unmarshal(out, creds); err != nil {
+			return nil, fmt.Errorf(`unmarshal output of "%s%s get": %v`, credHelperPrefix, helper, err)
+		}
+		return creds, nil
+	}
+	return nil, errors.ErrCredentialsNotFound
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/option"
+	"testing"
+)
+
+const fakeCredHelper = `#!/bin/sh
+read -r server
+case "$1 $server" in
+"list "*)
+    echo '{"https://registry.example.com/v2/":"alice","https://index.docker.io/v1/":"bob","token.example.com":"<token>"}' ;;
+"get https://registry.example.com/v2/")
+    echo '{"ServerURL":"https://registry.example.com/v2/","Username":"alice","Secret":"s3cret"}' ;;
+"get https://index.docker.io/v1/")
+    echo '{"ServerURL":"https://index.docker.io/v1/","Username":"bob","Secret":"hub"}' ;;
+"get token.example.com")
+    echo '{"ServerURL":"token.example.com","Username":"<token>","Secret":"refresh"}' ;;
+"get broken.example.com")
+    echo 'helper is broken' >&2; exit 2 ;;
+*)
+    echo 'credentials not found in native keychain'; exit 1 ;;
+esac
+`
+
+func TestCredHelper(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker-credential-fake"), []byte(fakeCredHelper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"credsStore":"fake","credHelpers":{"other.example.com":"missing"}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	cases := []struct {
+		server   string
+		username string
+		password string
+		token    string
+	}{
+		{server: "registry.example.com", username: "alice", password: "s3cret"},
+		{server: "docker.io", username: "bob", password: "hub"},
+		{server: "token.example.com", token: "refresh"},
+		{server: "unknown.example.com"},
+		{server: "broken.example.com"},
+		{server: "other.example.com"},
+	}
+	for _, c := range cases {
+		t.Run(c.server, func(t *testing.T) {
+			opts := &option.Options{Server: c.server, StdErr: &bytes.Buffer{}}
+			cs := &credstore{refreshTokens: map[string]string{}}
+			ok := cs.fromCredHelper(opts)
+			if ok != (c.username != "" || c.token != "") {
+				t.Errorf("expect found %v, but got %v", !ok, ok)
+			}
+			if cs.username != c.username || cs.password != c.password {
+				t.Errorf("expect %s:%s, but got %s:%s", c.username, c.password, cs.username, cs.password)
+			}
+			if token := cs.RefreshToken(nil, "registry"); token != c.token {
+				t.Errorf("expect refresh token %q, but got %q", c.token, token)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+
+	"github.com/docker/distribution/registry/api/errcode"
+)
+
+type authEntry struct {
+	Auth string `json:"auth"`
+}
+
+func authKey(server string) string {
+	if server == dockerHubServer {
+		return dockerHubIndexServer
+	}
+	return server
+}
+
+func (c *Client) Ping() error {
+	roundTripper, err := c.GetRoundTripperWithScopes()
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: roundTripper}).Get(c.baseURL + "/v2/")
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok && isUnauthorized(urlErr.Err) {
+			return errors.ErrLoginFailed
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.ErrLoginFailed
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("ping registry: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func isUnauthorized(err error) bool {
+	switch e := err.(type) {
+	case errcode.Error:
+		return e.Code == errcode.ErrorCodeUnauthorized
+	case errcode.Errors:
+		for _, err := range e {
+			if isUnauthorized(err) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func StoreCredentials(opts *option.Options) (string, error) {
+	key := authKey(opts.Server)
+	if opts.AuthFile == "" {
+		cfg, err := loadDockerConfig()
+		if err != nil {
+			return "", err
+		}
+		if helper := cfg.helper(opts.Server); helper != "" {
+			input, err := json.Marshal(helperCredentials{ServerURL: key, Username: opts.Username, Secret: opts.Password})
+			if err != nil {
+				return "", err
+			}
+			if _, err := runCredHelper(helper, "store", string(input)); err != nil {
+				return "", err
+			}
+			return credHelperPrefix + helper, nil
+		}
+	}
+
+	file := authFile(opts)
+	return file, editAuthFile(file, func(auths map[string]json.RawMessage) (bool, error) {
+		entry, err := json.Marshal(authEntry{Auth: makeAuth(opts.Username, opts.Password)})
+		if err != nil {
+			return false, err
+		}
+		auths[key] = entry
+		return true, nil
+	})
+}
+
+func RemoveCredentials(opts *option.Options) ([]string, error) {
+	var removed []string
+	if opts.AuthFile == "" {
+		cfg, err := loadDockerConfig()
+		if err != nil {
+			return nil, err
+		}
+		if helper := cfg.helper(opts.Server); helper != "" {
+			servers, err := listHelperServers(helper, opts.Server)
+			if err != nil {
+				return nil, err
+			}
+			erased := false
+			for _, server := range append([]string{authKey(opts.Server)}, servers...) {
+				_, err := runCredHelper(helper, "erase", server)
+				if err == errors.ErrCredentialsNotFound {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				erased = true
+			}
+			if erased {
+				removed = append(removed, credHelperPrefix+helper)
+			}
+		}
+	}
+
+	file := authFile(opts)
+	deleted := false
+	err := editAuthFile(file, func(auths map[string]json.RawMessage) (bool, error) {
+		for key := range auths {
+			if serverHost(key) == opts.Server {
+				delete(auths, key)
+				deleted = true
+			}
+		}
+		return deleted, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		removed = append(removed, file)
+	}
+	if len(removed) == 0 {
+		return nil, errors.ErrNotLoggedIn
+	}
+	return removed, nil
+}
+
+func authFile(opts *option.Options) string {
+	if opts.AuthFile != "" {
+		return opts.AuthFile
+	}
+	return dockerConfigFile()
+}
+
+func editAuthFile(file string, edit func(auths map[string]json.RawMessage) (bool, error)) error {
+	if file == "" {
+		return errors.ErrNeedAuthFile
+	}
+	cfg := map[string]json.RawMessage{}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf(`unmarshal "%s": %v`, file, err)
+		}
+	}
+	auths := map[string]json.RawMessage{}
+	if raw, ok := cfg["auths"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &auths); err != nil {
+			return fmt.Errorf(`unmarshal auths of "%s": %v`, file, err)
+		}
+	}
+
+	changed, err := edit(auths)
+	if err != nil || !changed {
+		return err
+	}
+	if cfg["auths"], err = json.Marshal(auths); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(file, append(out, '\n'))
+}
+
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
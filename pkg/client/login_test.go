@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"testing"
+)
+
+func TestStoreAndRemoveCredentials(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	config := `{"auths":{"https://127.0.0.1:5000/v2/":{"auth":"b2xkOm9sZA=="},"other:5000":{"auth":"eDp5","email":"x@example.com"}},"proxies":{"default":{"httpProxy":"http://proxy"}}}`
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &option.Options{Server: "127.0.0.1:5000", Username: "user", Password: "pass", AuthFile: file}
+
+	if _, err := StoreCredentials(opts); err != nil {
+		t.Fatal(err)
+	}
+	cfg := struct {
+		Auths   map[string]map[string]string `json:"auths"`
+		Proxies map[string]interface{}       `json:"proxies"`
+	}{}
+	read := func() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read()
+	if auth := cfg.Auths["127.0.0.1:5000"]["auth"]; auth != makeAuth("user", "pass") {
+		t.Errorf("expect auth of user:pass, but got %q", auth)
+	}
+	if cfg.Auths["other:5000"]["email"] != "x@example.com" || cfg.Proxies["default"] == nil {
+		t.Errorf("expect other fields to be kept, but got %v", cfg)
+	}
+
+	removed, err := RemoveCredentials(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != file {
+		t.Errorf("expect removed from %s, but got %v", file, removed)
+	}
+	cfg.Auths = nil
+	read()
+	if len(cfg.Auths) != 1 || cfg.Auths["other:5000"] == nil {
+		t.Errorf("expect only other:5000 left, but got %v", cfg.Auths)
+	}
+	if _, err := RemoveCredentials(opts); err != errors.ErrNotLoggedIn {
+		t.Errorf("expect %v, but got %v", errors.ErrNotLoggedIn, err)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"sort"
+	"strings"
+)
+
+var certsDir = "/etc/docker/certs.d"
+
+type tlsFiles struct {
+	caFiles []string
+	certs   [][2]string
+}
+
+func loadCertsDir(dir string) (*tlsFiles, error) {
+	files := &tlsFiles{}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names[entry.Name()] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		switch filepath.Ext(name) {
+		case ".crt":
+			files.caFiles = append(files.caFiles, filepath.Join(dir, name))
+		case ".cert":
+			key := strings.TrimSuffix(name, ".cert") + ".key"
+			if !names[key] {
+				return nil, fmt.Errorf(`missing key "%s" for client certificate "%s" in "%s"`, key, name, dir)
+			}
+			files.certs = append(files.certs, [2]string{filepath.Join(dir, name), filepath.Join(dir, key)})
+		case ".key":
+			cert := strings.TrimSuffix(name, ".key") + ".cert"
+			if !names[cert] {
+				return nil, fmt.Errorf(`missing client certificate "%s" for key "%s" in "%s"`, cert, name, dir)
+			}
+		}
+	}
+	return files, nil
+}
+
+func newTLSConfig(opts *option.Options) (*tls.Config, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.ErrNeedCertAndKey
+	}
+	cfg := &tls.Config{
+		InsecureSkipVerify: opts.Insecure,
+	}
+
+	files, err := loadCertsDir(filepath.Join(certsDir, opts.Server))
+	if err != nil {
+		return nil, err
+	}
+	if opts.CAFile != "" {
+		files.caFiles = append(files.caFiles, opts.CAFile)
+	}
+	if opts.CertFile != "" {
+		files.certs = append([][2]string{{opts.CertFile, opts.KeyFile}}, files.certs...)
+	}
+
+	if len(files.caFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			opts.WriteDebug("load system cert pool", err)
+			pool = x509.NewCertPool()
+		}
+		for _, file := range files.caFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf(`no PEM encoded certificate found in CA file "%s"`, file)
+			}
+			opts.WriteDebug(fmt.Sprintf(`loaded CA file "%s"`, file), nil)
+		}
+		cfg.RootCAs = pool
+	}
+
+	for _, pair := range files.certs {
+		cert, err := tls.LoadX509KeyPair(pair[0], pair[1])
+		if err != nil {
+			return nil, fmt.Errorf(`load client certificate "%s" and key "%s": %v`, pair[0], pair[1], err)
+		}
+		opts.WriteDebug(fmt.Sprintf(`loaded client certificate "%s"`, pair[0]), nil)
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"registry-cli/pkg/option"
+	"testing"
+)
+
+func TestLoadCertsDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ca.crt", "extra.crt", "client.cert", "client.key", "README"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := loadCertsDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files.caFiles) != 2 || files.caFiles[0] != filepath.Join(dir, "ca.crt") {
+		t.Errorf("expect ca.crt and extra.crt, but got %v", files.caFiles)
+	}
+	if len(files.certs) != 1 || files.certs[0][1] != filepath.Join(dir, "client.key") {
+		t.Errorf("expect client.cert with client.key, but got %v", files.certs)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "other.key"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCertsDir(dir); err == nil {
+		t.Error("expect error for key without certificate")
+	}
+
+	if files, err := loadCertsDir(filepath.Join(dir, "missing")); err != nil || len(files.caFiles)+len(files.certs) != 0 {
+		t.Errorf("expect nothing loaded from missing dir, but got %v, %v", files, err)
+	}
+
+	if _, err := newTLSConfig(&option.Options{Server: "127.0.0.1:5000", CertFile: "client.cert"}); err != errors.ErrNeedCertAndKey {
+		t.Errorf("expect %v, but got %v", errors.ErrNeedCertAndKey, err)
+	}
+}
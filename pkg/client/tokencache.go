@@ -0,0 +1,447 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/option"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/distribution/registry/client/auth"
+	"github.com/distribution/distribution/registry/client/transport"
+)
+
+const (
+	tokenCacheDir      = "registrycli"
+	tokenCacheFile     = "tokens.json"
+	tokenCacheKeyFile  = "key"
+	tokenCacheLockFile = "lock"
+	tokenLockTimeout   = 5 * time.Second
+	tokenLockStale     = 30 * time.Second
+	tokenCacheKeySize  = 32
+	tokenDefaultExpiry = 60 * time.Second
+	tokenExpirySkew    = 10 * time.Second
+	refreshTokenScope  = "refresh_token"
+)
+
+type tokenEntry struct {
+	Server  string    `json:"server"`
+	Expires time.Time `json:"expires,omitempty"`
+	Nonce   string    `json:"nonce"`
+	Data    string    `json:"data"`
+}
+
+type tokenCache struct {
+	file     string
+	server   string
+	username string
+	idKey    []byte
+	aead     cipher.AEAD
+
+	lock    sync.Mutex
+	entries map[string]tokenEntry
+}
+
+func tokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tokenCacheDir), nil
+}
+
+func loadTokenCacheKey(dir string) ([]byte, error) {
+	file := filepath.Join(dir, tokenCacheKeyFile)
+	key, err := os.ReadFile(file)
+	if err == nil && len(key) == tokenCacheKeySize {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	unlock, err := lockTokenCache(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if key, err := os.ReadFile(file); err == nil && len(key) == tokenCacheKeySize {
+		return key, nil
+	}
+	key = make([]byte, tokenCacheKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(file, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newTokenCache(opts *option.Options, cs *credstore) (*tokenCache, error) {
+	dir, err := tokenCachePath()
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadTokenCacheKey(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{"encrypt", opts.Server, cs.username, cs.password, cs.identityToken}, "\x00")))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &tokenCache{
+		file:     filepath.Join(dir, tokenCacheFile),
+		server:   opts.Server,
+		username: cs.username,
+		idKey:    key,
+		aead:     aead,
+	}
+	if c.entries, err = c.read(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *tokenCache) read() (map[string]tokenEntry, error) {
+	entries := map[string]tokenEntry{}
+	data, err := os.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf(`unmarshal "%s": %v`, c.file, err)
+	}
+	return entries, nil
+}
+
+func (c *tokenCache) id(service, scope string) string {
+	mac := hmac.New(sha256.New, c.idKey)
+	mac.Write([]byte(strings.Join([]string{"id", c.server, service, c.username, scope}, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *tokenCache) get(service, scope string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[c.id(service, scope)]
+	if !ok || !entry.Expires.IsZero() && time.Now().After(entry.Expires) {
+		return "", false
+	}
+	nonce, err := base64.StdEncoding.DecodeString(entry.Nonce)
+	if err != nil || len(nonce) != c.aead.NonceSize() {
+		return "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(entry.Data)
+	if err != nil {
+		return "", false
+	}
+	token, err := c.aead.Open(nil, nonce, data, []byte(c.server))
+	if err != nil {
+		return "", false
+	}
+	return string(token), true
+}
+
+func (c *tokenCache) set(service, scope, token string, expires time.Time) error {
+	if current, ok := c.get(service, scope); ok && current == token {
+		return nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	entry := tokenEntry{
+		Server:  c.server,
+		Expires: expires,
+		Nonce:   base64.StdEncoding.EncodeToString(nonce),
+		Data:    base64.StdEncoding.EncodeToString(c.aead.Seal(nil, nonce, []byte(token), []byte(c.server))),
+	}
+	id := c.id(service, scope)
+	return c.update(func(entries map[string]tokenEntry) {
+		entries[id] = entry
+	})
+}
+
+func (c *tokenCache) remove(service, scope string) error {
+	id := c.id(service, scope)
+	return c.update(func(entries map[string]tokenEntry) {
+		delete(entries, id)
+	})
+}
+
+func lockTokenCache(dir string) (func(), error) {
+	file := filepath.Join(dir, tokenCacheLockFile)
+	deadline := time.Now().Add(tokenLockTimeout)
+	for {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(file) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > tokenLockStale {
+			os.Remove(file)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf(`lock "%s": timeout`, file)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (c *tokenCache) update(edit func(entries map[string]tokenEntry)) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	unlock, err := lockTokenCache(filepath.Dir(c.file))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+	edit(entries)
+	c.entries = pruneTokenEntries(entries)
+	return writeTokenEntries(c.file, c.entries)
+}
+
+func pruneTokenEntries(entries map[string]tokenEntry) map[string]tokenEntry {
+	now := time.Now()
+	for id, entry := range entries {
+		if !entry.Expires.IsZero() && now.After(entry.Expires) {
+			delete(entries, id)
+		}
+	}
+	return entries
+}
+
+func writeTokenEntries(file string, entries map[string]tokenEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(file, data)
+}
+
+func ClearTokenCache(server string) error {
+	dir, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	c := &tokenCache{file: filepath.Join(dir, tokenCacheFile)}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := lockTokenCache(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := c.read()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	for id, entry := range entries {
+		if entry.Server == server {
+			delete(entries, id)
+		}
+	}
+	return writeTokenEntries(c.file, pruneTokenEntries(entries))
+}
+
+func tokenExpiry(token string) time.Time {
+	now := time.Now()
+	expires := now.Add(tokenDefaultExpiry)
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		claims := struct {
+			Expires int64 `json:"exp"`
+		}{}
+		if payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "=")); err == nil {
+			if json.Unmarshal(payload, &claims) == nil && claims.Expires > 0 {
+				expires = time.Unix(claims.Expires, 0)
+			}
+		}
+	}
+	return expires.Add(-tokenExpirySkew)
+}
+
+type cachedCredStore struct {
+	auth.CredentialStore
+	cache *tokenCache
+	opts  *option.Options
+}
+
+func (cs *cachedCredStore) RefreshToken(u *url.URL, service string) string {
+	if token := cs.CredentialStore.RefreshToken(u, service); token != "" {
+		return token
+	}
+	token, _ := cs.cache.get(service, refreshTokenScope)
+	return token
+}
+
+func (cs *cachedCredStore) SetRefreshToken(u *url.URL, service string, token string) {
+	cs.CredentialStore.SetRefreshToken(u, service, token)
+	if err := cs.cache.set(service, refreshTokenScope, token, time.Time{}); err != nil {
+		cs.opts.WriteDebug("save refresh token", err)
+	}
+}
+
+type cachedTokenKey struct {
+	service string
+	scope   string
+}
+
+type cachedTokenHandler struct {
+	auth.AuthenticationHandler
+	cache  *tokenCache
+	scopes []string
+	opts   *option.Options
+
+	lock    sync.Mutex
+	served  map[string]cachedTokenKey
+	fetched map[string]bool
+}
+
+func (h *cachedTokenHandler) markServed(token string, key cachedTokenKey) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.fetched[token] {
+		return
+	}
+	if h.served == nil {
+		h.served = map[string]cachedTokenKey{}
+	}
+	h.served[token] = key
+}
+
+func (h *cachedTokenHandler) markFetched(token string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.fetched == nil {
+		h.fetched = map[string]bool{}
+	}
+	h.fetched[token] = true
+	delete(h.served, token)
+}
+
+func (h *cachedTokenHandler) invalidate(token string) bool {
+	h.lock.Lock()
+	key, ok := h.served[token]
+	delete(h.served, token)
+	h.lock.Unlock()
+	if !ok {
+		return false
+	}
+	h.opts.WriteDebug("drop rejected cached token", nil)
+	if err := h.cache.remove(key.service, key.scope); err != nil {
+		h.opts.WriteDebug("remove token", err)
+	}
+	return true
+}
+
+func (h *cachedTokenHandler) AuthorizeRequest(req *http.Request, params map[string]string) error {
+	scopes := append([]string{}, h.scopes...)
+	if from := req.URL.Query().Get("from"); from != "" {
+		scopes = append(scopes, auth.RepositoryScope{Repository: from, Actions: []string{string(PullAction)}}.String())
+	}
+	sort.Strings(scopes)
+	service, scope := params["service"], strings.Join(scopes, " ")
+
+	if token, ok := h.cache.get(service, scope); ok {
+		h.markServed(token, cachedTokenKey{service: service, scope: scope})
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	err := h.AuthenticationHandler.AuthorizeRequest(req, params)
+	if err != nil {
+		if _, ok := h.cache.get(service, refreshTokenScope); !ok {
+			return err
+		}
+		h.opts.WriteDebug("drop cached refresh token", err)
+		if removeErr := h.cache.remove(service, refreshTokenScope); removeErr != nil {
+			h.opts.WriteDebug("remove refresh token", removeErr)
+		}
+		if err = h.AuthenticationHandler.AuthorizeRequest(req, params); err != nil {
+			return err
+		}
+	}
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	h.markFetched(token)
+	if err := h.cache.set(service, scope, token, tokenExpiry(token)); err != nil {
+		h.opts.WriteDebug("save token", err)
+	}
+	return nil
+}
+
+type cachedTokenTransport struct {
+	base       http.RoundTripper
+	handler    *cachedTokenHandler
+	authorizer transport.RequestModifier
+}
+
+func (t *cachedTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || req.Body != nil && req.GetBody == nil || !t.handler.invalidate(token) {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	retry.Header.Del("Authorization")
+	if err := t.authorizer.ModifyRequest(retry); err != nil {
+		if retry.Body != nil {
+			retry.Body.Close()
+		}
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"registry-cli/pkg/option"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	opts := &option.Options{Server: "registry.example.com"}
+	open := func(password string) *tokenCache {
+		c, err := newTokenCache(opts, &credstore{username: "alice", password: password})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	c := open("s3cret")
+	if err := c.set("registry", "repository:repo1:pull", "token1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.set("registry", "repository:repo2:pull", "expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	c = open("s3cret")
+	if token, ok := c.get("registry", "repository:repo1:pull"); !ok || token != "token1" {
+		t.Errorf("expect cached token1, but got %q", token)
+	}
+	if _, ok := c.get("registry", "repository:repo2:pull"); ok {
+		t.Error("expect expired token to be dropped")
+	}
+	if _, ok := open("wrong").get("registry", "repository:repo1:pull"); ok {
+		t.Error("expect token not readable with other credentials")
+	}
+
+	if err := ClearTokenCache(opts.Server); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := open("s3cret").get("registry", "repository:repo1:pull"); ok {
+		t.Error("expect token removed after clearing the cache")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp)))
+	if got := tokenExpiry("header." + payload + ".signature"); !got.Equal(time.Unix(exp, 0).Add(-tokenExpirySkew)) {
+		t.Errorf("expect expiry from jwt claims, but got %v", got)
+	}
+	if got := time.Until(tokenExpiry("opaque")); got > tokenDefaultExpiry || got < tokenDefaultExpiry-2*tokenExpirySkew {
+		t.Errorf("expect default expiry, but got %v", got)
+	}
+}
+
+func TestCachedTokenRetry(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	issued := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			issued++
+			fmt.Fprint(w, `{"token":"fresh"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	opts := &option.Options{Server: strings.TrimPrefix(server.URL, "http://"), Username: "u", Password: "p", PlainHTTP: true, TokenCache: true}
+	cli, err := NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := "repository:repo1:pull"
+	if err := cli.tokenCache.set("registry", scope, "stale", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	roundTripper, err := cli.GetRoundTripper("repo1", PullAction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v2/repo1/blobs/uploads/", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := roundTripper.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "body" {
+		t.Errorf("expect retry with a fresh token, but got %d %q", resp.StatusCode, body)
+	}
+	if token, ok := cli.tokenCache.get("registry", scope); !ok || token != "fresh" || issued != 1 {
+		t.Errorf("expect fresh token cached after one request, but got %q after %d requests", token, issued)
+	}
+}
+
+func TestTokenCacheConcurrentUpdate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	opts := &option.Options{Server: "registry.example.com"}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		c, err := newTokenCache(opts, &credstore{username: "alice", password: "s3cret"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			if err := c.set("registry", fmt.Sprintf("repository:repo%d:pull", i), "token", time.Now().Add(time.Minute)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	c, err := newTokenCache(opts, &credstore{username: "alice", password: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, ok := c.get("registry", fmt.Sprintf("repository:repo%d:pull", i)); !ok {
+			t.Errorf("expect token of repo%d to be kept", i)
+		}
+	}
+}
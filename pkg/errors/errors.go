@@ -12,4 +12,39 @@ var (
 	ErrUnknownOutput        = errors.New("unknown output format")
 	ErrUnknownSort          = errors.New("unknown sort method")
 	ErrUnknownManifest      = errors.New("unknown manifest")
+	ErrNeedTarget           = errors.New("need target image reference")
+	ErrDigestMismatch       = errors.New("digest mismatch")
+	ErrNeedRetentionRule    = errors.New("need at least one retention rule")
+	ErrManifestNotFound     = errors.New("manifest not found")
+	ErrSizeMismatch         = errors.New("size mismatch")
+	ErrNeedPullDestination  = errors.New("need one of oci layout directory or archive file to pull into")
+	ErrNeedPushSource       = errors.New("need one of oci layout directory or archive file to push from")
+	ErrNeedSourceImage      = errors.New("can not determine which image to push, use the tag of the source image")
+	ErrPlatformNotFound     = errors.New("no manifest matches the platform")
+	ErrFileNotFound         = errors.New("file not found in image")
+	ErrIsDirectory          = errors.New("path is a directory")
+	ErrTooManyLinks         = errors.New("too many levels of symbolic links")
+	ErrStopWalk             = errors.New("stop walking layer")
+	ErrVerifyFailed         = errors.New("verify failed, some blobs are missing or corrupted")
+	ErrNeedPublicKey        = errors.New("need public key file")
+	ErrInvalidPublicKey     = errors.New("invalid public key, need a PEM encoded ecdsa, rsa or ed25519 public key")
+	ErrSignatureNotFound    = errors.New("no signature found for the image")
+	ErrSignatureMissing     = errors.New("signature annotation is missing")
+	ErrSignatureMismatch    = errors.New("signature does not match the public key")
+	ErrNoValidSignature     = errors.New("no valid signature for the image")
+	ErrSBOMNotFound         = errors.New("no sbom found for the image")
+	ErrUnknownSBOM          = errors.New("unknown sbom format")
+	ErrNeedAdvisoryDB       = errors.New("need advisory database file")
+	ErrCredentialsNotFound  = errors.New("credentials not found in credential helper")
+	ErrPasswordConflict     = errors.New("only one of --password, --password-stdin and --password-file can be used")
+	ErrEmptyPassword        = errors.New("password is empty")
+	ErrNeedUsername         = errors.New("need username with password, use --username or REGISTRYCLI_USERNAME")
+	ErrNeedPassword         = errors.New("need password, use --password-stdin, --password-file or REGISTRYCLI_PASSWORD")
+	ErrLoginFailed          = errors.New("login failed, username or password is incorrect")
+	ErrNotLoggedIn          = errors.New("not logged in")
+	ErrNeedAuthFile         = errors.New("can not locate docker config file, use --auth-file")
+	ErrNeedCertAndKey       = errors.New("--cert and --key must be specified together")
+	ErrStdinConflict        = errors.New("only one of --password-stdin and --dest-password-stdin can be used")
+	ErrPermissionDenied     = errors.New("permission denied")
+	ErrPackageDBUnsupported = errors.New("some package databases can not be read, the scan result is incomplete")
 )
@@ -0,0 +1,85 @@
+package option
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"registry-cli/pkg/errors"
+	"strings"
+)
+
+const (
+	UsernameEnv = "REGISTRYCLI_USERNAME"
+	PasswordEnv = "REGISTRYCLI_PASSWORD"
+)
+
+func (opts *Options) LoadCredentials(stdin io.Reader) error {
+	return opts.loadCredentials(stdin, true)
+}
+
+func (opts *Options) loadCredentials(stdin io.Reader, env bool) error {
+	if opts.PasswordStdin && opts.PasswordFile != "" || (opts.PasswordStdin || opts.PasswordFile != "") && opts.Password != "" {
+		return errors.ErrPasswordConflict
+	}
+
+	switch {
+	case opts.PasswordStdin:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("read password from stdin error: %v", err)
+		}
+		opts.Password = strings.TrimRight(string(data), "\r\n")
+	case opts.PasswordFile != "":
+		data, err := os.ReadFile(opts.PasswordFile)
+		if err != nil {
+			return fmt.Errorf(`read password file "%s" error: %v`, opts.PasswordFile, err)
+		}
+		opts.Password = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if env && opts.Auth == "" && opts.Username == "" {
+		opts.Username = os.Getenv(UsernameEnv)
+	}
+	if opts.PasswordStdin || opts.PasswordFile != "" {
+		if opts.Password == "" {
+			return errors.ErrEmptyPassword
+		}
+		if opts.Username == "" {
+			return errors.ErrNeedUsername
+		}
+	}
+	if env && opts.Auth == "" && opts.Username != "" && opts.Password == "" {
+		opts.Password = os.Getenv(PasswordEnv)
+	}
+	return nil
+}
+
+func (opts *Options) ForReference(ref string) (*Options, error) {
+	target := *opts
+	target.Tag, target.Digest, target.Target = "", "", nil
+	if err := target.ParseReference(ref); err != nil {
+		return nil, err
+	}
+	if target.Server != opts.Server {
+		target.clearCredentials()
+	}
+	return &target, nil
+}
+
+func (opts *Options) LoadDestCredentials(target *Options, stdin io.Reader) error {
+	if opts.DestUsername == "" && opts.DestPassword == "" && !opts.DestPasswordStdin && opts.DestPasswordFile == "" {
+		return nil
+	}
+	if opts.PasswordStdin && opts.DestPasswordStdin {
+		return errors.ErrStdinConflict
+	}
+	target.clearCredentials()
+	target.Username, target.Password = opts.DestUsername, opts.DestPassword
+	target.PasswordStdin, target.PasswordFile = opts.DestPasswordStdin, opts.DestPasswordFile
+	return target.loadCredentials(stdin, false)
+}
+
+func (opts *Options) clearCredentials() {
+	opts.Username, opts.Password, opts.Auth = "", "", ""
+	opts.PasswordStdin, opts.PasswordFile = false, ""
+}
@@ -0,0 +1,98 @@
+package option
+
+import (
+	"os"
+	"path/filepath"
+	"registry-cli/pkg/errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name     string
+		opts     Options
+		stdin    string
+		env      map[string]string
+		username string
+		password string
+		err      error
+	}{
+		{name: "flags", opts: Options{Username: "u", Password: "p"}, env: map[string]string{PasswordEnv: "env"}, username: "u", password: "p"},
+		{name: "stdin", opts: Options{Username: "u", PasswordStdin: true}, stdin: "from-stdin\r\n", username: "u", password: "from-stdin"},
+		{name: "file", opts: Options{Username: "u", PasswordFile: file}, username: "u", password: "from-file"},
+		{name: "env", env: map[string]string{UsernameEnv: "eu", PasswordEnv: "ep"}, username: "eu", password: "ep"},
+		{name: "env password", opts: Options{Username: "u"}, env: map[string]string{PasswordEnv: "ep"}, username: "u", password: "ep"},
+		{name: "env username with stdin", opts: Options{PasswordStdin: true}, stdin: "s", env: map[string]string{UsernameEnv: "eu"}, username: "eu", password: "s"},
+		{name: "auth", opts: Options{Auth: "dTpw"}, env: map[string]string{UsernameEnv: "eu", PasswordEnv: "ep"}},
+		{name: "no username", opts: Options{PasswordStdin: true}, stdin: "s", err: errors.ErrNeedUsername},
+		{name: "empty stdin", opts: Options{Username: "u", PasswordStdin: true}, err: errors.ErrEmptyPassword},
+		{name: "stdin and file", opts: Options{Username: "u", PasswordStdin: true, PasswordFile: file}, err: errors.ErrPasswordConflict},
+		{name: "password and file", opts: Options{Username: "u", Password: "p", PasswordFile: file}, err: errors.ErrPasswordConflict},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv(UsernameEnv, c.env[UsernameEnv])
+			t.Setenv(PasswordEnv, c.env[PasswordEnv])
+			opts := c.opts
+			err := opts.LoadCredentials(strings.NewReader(c.stdin))
+			if err != c.err {
+				t.Fatalf("expect error %v, but got %v", c.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if opts.Username != c.username || opts.Password != c.password {
+				t.Errorf("expect %s:%s, but got %s:%s", c.username, c.password, opts.Username, opts.Password)
+			}
+		})
+	}
+}
+
+func TestForReference(t *testing.T) {
+	t.Setenv(UsernameEnv, "eu")
+	t.Setenv(PasswordEnv, "ep")
+	opts := &Options{Username: "u", Password: "p", Auth: "dTpw", Server: "127.0.0.1:5000", Repositiory: "repo1", Tag: "v1"}
+
+	same, err := opts.ForReference("127.0.0.1:5000/repo2:v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.Username != "u" || same.Password != "p" || same.Auth != "dTpw" || same.Repositiory != "repo2" || same.Tag != "v2" {
+		t.Errorf("expect credentials kept for the same server, but got %+v", same)
+	}
+
+	other, err := opts.ForReference("127.0.0.1:5001/repo2@sha256:74f5f150164eb49b3e6f621751a353dbfbc1dd114eb9b651ef8b1b4f5cc0c0d5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Username != "" || other.Password != "" || other.Auth != "" || other.Tag != "" {
+		t.Errorf("expect credentials cleared for another server, but got %+v", other)
+	}
+
+	opts.DestUsername, opts.DestPasswordStdin = "d", true
+	if err := opts.LoadDestCredentials(other, strings.NewReader("dp\n")); err != nil {
+		t.Fatal(err)
+	}
+	if other.Username != "d" || other.Password != "dp" {
+		t.Errorf("expect d:dp, but got %s:%s", other.Username, other.Password)
+	}
+
+	opts.DestPasswordStdin = false
+	if err := opts.LoadDestCredentials(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if other.Username != "d" || other.Password != "" {
+		t.Errorf("expect d without password, but got %s:%s", other.Username, other.Password)
+	}
+
+	opts.DestPasswordStdin = true
+	opts.PasswordStdin = true
+	if err := opts.LoadDestCredentials(other, strings.NewReader("dp")); err != errors.ErrStdinConflict {
+		t.Errorf("expect error %v, but got %v", errors.ErrStdinConflict, err)
+	}
+}
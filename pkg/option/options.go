@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/docker/distribution/reference"
@@ -33,26 +35,56 @@ var (
 )
 
 type Options struct {
-	Username    string
-	Password    string
-	Auth        string
-	Server      string
-	Repositiory string
-	Tag         string
-	Digest      digest.Digest
-	Output      string
-	Sort        string
-	Destination string
-	Debug       bool
-	ShowType    bool
-	ShowDigest  bool
-	ShowSummary bool
-	Insecure    bool
-	PlainHTTP   bool
-	Untag       bool
-	StdErr      io.Writer
-	StdOut      io.Writer
-	Ctx         context.Context
+	Username          string
+	Password          string
+	PasswordFile      string
+	DestUsername      string
+	DestPassword      string
+	DestPasswordFile  string
+	Auth              string
+	Server            string
+	Repositiory       string
+	Tag               string
+	Digest            digest.Digest
+	Output            string
+	Sort              string
+	Platform          string
+	ArtifactType      string
+	Destination       string
+	Path              string
+	Key               string
+	AdvisoryDB        string
+	AuthFile          string
+	CAFile            string
+	CertFile          string
+	KeyFile           string
+	BaseImages        []string
+	OCILayout         string
+	Archive           string
+	KeepTag           string
+	KeepLast          int
+	ChunkSize         int64
+	OlderThan         time.Duration
+	Debug             bool
+	ShowType          bool
+	ShowDigest        bool
+	ShowSummary       bool
+	ShowFiles         bool
+	ShowArtifacts     bool
+	ShowBase          bool
+	PasswordStdin     bool
+	DestPasswordStdin bool
+	TokenCache        bool
+	Insecure          bool
+	PlainHTTP         bool
+	Untag             bool
+	List              bool
+	Raw               bool
+	DryRun            bool
+	Target            *Options
+	StdErr            io.Writer
+	StdOut            io.Writer
+	Ctx               context.Context
 }
 
 func (opts *Options) ParseReference(ref string) error {
@@ -72,6 +104,32 @@ func (opts *Options) ParseReference(ref string) error {
 	return nil
 }
 
+func (opts *Options) ParseRepositoryReference(ref string) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return fmt.Errorf(`parse repository reference "%s" error: %v`, ref, err)
+	}
+	opts.Server = reference.Domain(named)
+	opts.Repositiory = reference.Path(named)
+	if namedTaged, ok := named.(reference.NamedTagged); ok {
+		opts.Tag = namedTaged.Tag()
+	}
+	if canonical, ok := named.(reference.Canonical); ok {
+		opts.Digest = canonical.Digest()
+	}
+
+	return nil
+}
+
+func (opts *Options) ParseFileReference(ref string) error {
+	i := strings.LastIndex(ref, ":/")
+	if i < 0 {
+		return fmt.Errorf(`parse file reference "%s" error: need ":/path" after the image reference`, ref)
+	}
+	opts.Path = path.Clean(ref[i+1:])
+	return opts.ParseReference(ref[:i])
+}
+
 func (opts *Options) IsSupportedOutput(supports ...string) bool {
 	if opts == nil {
 		return false
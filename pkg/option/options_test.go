@@ -57,3 +57,143 @@ func TestParseReference(t *testing.T) {
 	}
 
 }
+
+func TestParseFileReference(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		repo  string
+		tag   string
+		path  string
+		err   bool
+	}{
+		{
+			input: "127.0.0.1:5000/repo1:v1:/etc/os-release",
+			repo:  "repo1",
+			tag:   "v1",
+			path:  "/etc/os-release",
+		},
+		{
+			input: "127.0.0.1:5000/repo1:/etc/../etc/passwd",
+			repo:  "repo1",
+			tag:   "latest",
+			path:  "/etc/passwd",
+		},
+		{
+			input: "127.0.0.1:5000/repo1:v1",
+			err:   true,
+		},
+	} {
+		opts := &Options{}
+		err := opts.ParseFileReference(c.input)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expect err %v, but got %v", c.input, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if opts.Repositiory != c.repo {
+			t.Errorf("expect repository %s, but got %s", c.repo, opts.Repositiory)
+		}
+		if opts.Tag != c.tag {
+			t.Errorf("expect tag %s, but got %s", c.tag, opts.Tag)
+		}
+		if opts.Path != c.path {
+			t.Errorf("expect path %s, but got %s", c.path, opts.Path)
+		}
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	for _, c := range []struct {
+		input   string
+		expect  Platform
+		matches [][3]string
+		err     bool
+	}{
+		{
+			input:   "linux/amd64",
+			expect:  Platform{OS: "linux", Architecture: "amd64"},
+			matches: [][3]string{{"linux", "amd64", ""}, {"linux", "x86_64", ""}},
+		},
+		{
+			input:   "linux/arm64",
+			expect:  Platform{OS: "linux", Architecture: "arm64"},
+			matches: [][3]string{{"linux", "arm64", "v8"}, {"linux", "aarch64", ""}},
+		},
+		{
+			input:   "linux/arm",
+			expect:  Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+			matches: [][3]string{{"linux", "arm", "v7"}, {"linux", "armhf", ""}},
+		},
+		{
+			input:   "linux/arm/v7",
+			expect:  Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+			matches: [][3]string{{"linux", "arm", "v6"}, {"linux", "arm", "v5"}, {"linux", "armel", ""}},
+		},
+		{
+			input:  "linux/arm/v6",
+			expect: Platform{OS: "linux", Architecture: "arm", Variant: "v6"},
+		},
+		{
+			input:   "linux/arm64/v8",
+			expect:  Platform{OS: "linux", Architecture: "arm64"},
+			matches: [][3]string{{"linux", "arm64", ""}, {"linux", "arm", "v7"}},
+		},
+		{
+			input: "linux",
+			err:   true,
+		},
+		{
+			input: "linux//v7",
+			err:   true,
+		},
+	} {
+		p, err := ParsePlatform(c.input)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expect err %v, but got %v", c.input, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if p != c.expect {
+			t.Errorf("%s: expect %v, but got %v", c.input, c.expect, p)
+		}
+		for _, m := range c.matches {
+			if !p.Match(m[0], m[1], m[2]) {
+				t.Errorf("%s: expect to match %v", c.input, m)
+			}
+		}
+		if p.Match("windows", "amd64", "") {
+			t.Errorf("%s: expect not to match windows/amd64", c.input)
+		}
+	}
+}
+
+func TestPlatformRank(t *testing.T) {
+	for _, c := range []struct {
+		platform string
+		target   [3]string
+		rank     int
+	}{
+		{platform: "linux/arm/v7", target: [3]string{"linux", "arm", ""}, rank: 0},
+		{platform: "linux/arm/v7", target: [3]string{"linux", "arm", "v6"}, rank: 1},
+		{platform: "linux/arm/v7", target: [3]string{"linux", "arm", "v5"}, rank: 2},
+		{platform: "linux/arm/v6", target: [3]string{"linux", "arm", "v7"}, rank: -1},
+		{platform: "linux/arm64", target: [3]string{"linux", "arm64", "8"}, rank: 0},
+		{platform: "linux/arm64", target: [3]string{"linux", "arm", "v8"}, rank: 1},
+		{platform: "linux/arm64", target: [3]string{"linux", "arm", "v7"}, rank: 2},
+		{platform: "linux/amd64/v3", target: [3]string{"linux", "amd64", ""}, rank: 2},
+		{platform: "linux/amd64", target: [3]string{"linux", "amd64", "v2"}, rank: -1},
+		{platform: "linux/amd64", target: [3]string{"linux", "386", ""}, rank: -1},
+	} {
+		p, err := ParsePlatform(c.platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rank := p.Rank(c.target[0], c.target[1], c.target[2]); rank != c.rank {
+			t.Errorf("%s: expect rank %d for %v, but got %d", c.platform, c.rank, c.target, rank)
+		}
+	}
+}
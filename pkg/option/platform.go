@@ -0,0 +1,126 @@
+package option
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+func (p Platform) Match(os, arch, variant string) bool {
+	return p.Rank(os, arch, variant) >= 0
+}
+
+func (p Platform) Rank(os, arch, variant string) int {
+	other := NormalizePlatform(os, arch, variant)
+	for i, compatible := range p.compatible() {
+		if compatible == other {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p Platform) compatible() []Platform {
+	platforms := []Platform{p}
+	switch p.Architecture {
+	case "arm64":
+		if p.Variant != "" {
+			platforms = append(platforms, Platform{OS: p.OS, Architecture: "arm64"})
+		}
+		platforms = append(platforms, Platform{OS: p.OS, Architecture: "arm", Variant: "v8"}.compatible()...)
+	case "arm":
+		if v, err := strconv.Atoi(strings.TrimPrefix(p.Variant, "v")); err == nil {
+			for v--; v >= 5; v-- {
+				platforms = append(platforms, Platform{OS: p.OS, Architecture: "arm", Variant: "v" + strconv.Itoa(v)})
+			}
+		}
+	case "amd64":
+		if v, err := strconv.Atoi(strings.TrimPrefix(p.Variant, "v")); err == nil && v > 1 {
+			for v--; v >= 2; v-- {
+				platforms = append(platforms, Platform{OS: p.OS, Architecture: "amd64", Variant: "v" + strconv.Itoa(v)})
+			}
+			platforms = append(platforms, Platform{OS: p.OS, Architecture: "amd64"})
+		}
+	}
+	return platforms
+}
+
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf(`parse platform "%s" error: expect os/arch[/variant]`, s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return Platform{}, fmt.Errorf(`parse platform "%s" error: expect os/arch[/variant]`, s)
+		}
+	}
+	variant := ""
+	if len(parts) == 3 {
+		variant = parts[2]
+	}
+	return NormalizePlatform(parts[0], parts[1], variant), nil
+}
+
+func DefaultPlatform() Platform {
+	return NormalizePlatform("linux", runtime.GOARCH, "")
+}
+
+func NormalizePlatform(os, arch, variant string) Platform {
+	os, arch, variant = strings.ToLower(os), strings.ToLower(arch), strings.ToLower(variant)
+	if os == "macos" {
+		os = "darwin"
+	}
+	switch arch {
+	case "i386":
+		arch, variant = "386", ""
+	case "x86_64", "x86-64", "amd64":
+		arch = "amd64"
+		if variant == "v1" {
+			variant = ""
+		}
+	case "aarch64", "arm64":
+		arch = "arm64"
+		if variant == "8" || variant == "v8" {
+			variant = ""
+		}
+	case "armhf":
+		arch, variant = "arm", "v7"
+	case "armel":
+		arch, variant = "arm", "v6"
+	case "arm":
+		switch variant {
+		case "", "7":
+			variant = "v7"
+		case "5", "6", "8":
+			variant = "v" + variant
+		}
+	}
+	return Platform{OS: os, Architecture: arch, Variant: variant}
+}
+
+func (opts *Options) SelectedPlatform() (Platform, bool) {
+	if opts.Platform == "" {
+		return DefaultPlatform(), false
+	}
+	p, err := ParsePlatform(opts.Platform)
+	if err != nil {
+		return DefaultPlatform(), false
+	}
+	return p, true
+}
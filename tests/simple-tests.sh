@@ -19,10 +19,18 @@ function prepare_registry() {
 
 function test_repos() {
     ${T} repos 127.0.0.1:5000 --plain-http
+    echo password | ${T} repos 127.0.0.1:5000 -u user --password-stdin --plain-http
+    REGISTRYCLI_USERNAME=user REGISTRYCLI_PASSWORD=password ${T} repos 127.0.0.1:5000 --plain-http
+    ! echo password | ${T} repos 127.0.0.1:5000 --password-stdin --plain-http
+    ! ${T} repos 127.0.0.1:5000 --cert client.cert --plain-http
+    ! ${T} repos 127.0.0.1:5000 --tls-cert client.cert --plain-http
 }
 
 function test_tags() {
     ${T} tags 127.0.0.1:5000/repo1 --plain-http
+    ${T} tags 127.0.0.1:5000/repo1 --platform linux/amd64 --plain-http
+    ${T} tags 127.0.0.1:5000/repo1 --show-artifacts --plain-http
+    ${T} tags 127.0.0.1:5000/repo1 --show-base --base-image 127.0.0.1:5000/repo1:v1.0 --plain-http
 }
 
 function test_inspect() {
@@ -31,6 +39,76 @@ function test_inspect() {
 
 function test_layer() {
     ${T} layer 127.0.0.1:5000/repo1@sha256:36842a4bab9b581f82e33fc5af9caa57f977c591fd02a6e0047887ad3ab424c3 --plain-http
+    ${T} layer 127.0.0.1:5000/repo1@sha256:36842a4bab9b581f82e33fc5af9caa57f977c591fd02a6e0047887ad3ab424c3 --list --plain-http
+}
+
+function test_pull() {
+    ${T} pull 127.0.0.1:5000/repo1:v1.0 --oci-layout /tmp/repo1-layout --plain-http
+    ${T} pull 127.0.0.1:5000/repo1:v1.0 --archive /tmp/repo1.tar --plain-http
+}
+
+function test_push() {
+    ${T} push 127.0.0.1:5000/repo4:v1.0 --oci-layout /tmp/repo1-layout --plain-http
+    ${T} push 127.0.0.1:5000/repo4:v1.1 --archive /tmp/repo1.tar --chunk-size 1024 --plain-http
+}
+
+function test_verify() {
+    ${T} verify 127.0.0.1:5000/repo1:v1.0 --plain-http
+    ${T} verify 127.0.0.1:5000/repo1:v1.0 -o json --plain-http
+}
+
+function test_cat() {
+    ${T} cat 127.0.0.1:5000/repo1:v1.0:/etc/os-release --plain-http
+}
+
+function test_files() {
+    ${T} files 127.0.0.1:5000/repo1:v1.0 --plain-http
+}
+
+function test_diff() {
+    ${T} diff 127.0.0.1:5000/repo1:v1.0 127.0.0.1:5000/repo2:v1.0 --files --plain-http
+}
+
+function test_history() {
+    ${T} history 127.0.0.1:5000/repo1:v1.0 --plain-http
+}
+
+function test_referrers() {
+    ${T} referrers 127.0.0.1:5000/repo1:v1.0 --plain-http
+}
+
+function test_verify_signature() {
+    openssl ecparam -genkey -name prime256v1 | openssl ec -pubout > /tmp/cosign.pub
+    # the images in the test registry are unsigned
+    ! ${T} verify-signature 127.0.0.1:5000/repo1:v1.0 --key /tmp/cosign.pub --plain-http
+}
+
+function test_sbom() {
+    # the images in the test registry have no sbom attached
+    ! ${T} sbom 127.0.0.1:5000/repo1:v1.0 --plain-http
+}
+
+function test_scan() {
+    echo '[]' > /tmp/osv.json
+    ${T} scan 127.0.0.1:5000/repo1 --db /tmp/osv.json --plain-http
+    ${T} scan 127.0.0.1:5000/repo1:v1.0 --db /tmp/osv.json --plain-http -o json
+}
+
+function test_login() {
+    local config=$(mktemp -d)
+    echo password | DOCKER_CONFIG=${config} ${T} login 127.0.0.1:5000 -u user --password-stdin --plain-http
+    DOCKER_CONFIG=${config} ${T} repos 127.0.0.1:5000 --plain-http
+    DOCKER_CONFIG=${config} ${T} logout 127.0.0.1:5000
+    ! DOCKER_CONFIG=${config} ${T} logout 127.0.0.1:5000
+    rm -rf ${config}
+}
+
+function test_copy() {
+    ${T} copy 127.0.0.1:5000/repo1:v1.0 127.0.0.1:5000/repo3:v1.0 --plain-http
+}
+
+function test_prune() {
+    ${T} prune 127.0.0.1:5000/repo3 --keep-last 1 --plain-http
 }
 
 function test_del() {
@@ -44,6 +122,20 @@ function main() {
     test_tags
     test_inspect
     test_layer
+    test_pull
+    test_push
+    test_verify
+    test_cat
+    test_files
+    test_diff
+    test_history
+    test_referrers
+    test_verify_signature
+    test_sbom
+    test_scan
+    test_login
+    test_copy
+    test_prune
     test_del
 }
 